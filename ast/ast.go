@@ -2,6 +2,7 @@ package ast
 
 import (
 	"bytes"
+	"math/big"
 	"monkey/token"
 	"strings"
 )
@@ -9,6 +10,8 @@ import (
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position // position of the first character belonging to the node
+	End() token.Position // position immediately after the node
 }
 
 type Statement interface {
@@ -34,6 +37,18 @@ func (p *Program) TokenLiteral() string {
 	}
 	return ""
 }
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+func (p *Program) End() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[len(p.Statements)-1].End()
+	}
+	return token.Position{}
+}
 func (p *Program) String() string {
 	var out bytes.Buffer
 
@@ -57,6 +72,18 @@ func (ls *LetStatement) statementNode() {}
 func (ls *LetStatement) TokenLiteral() string {
 	return ls.Token.Literal
 }
+func (ls *LetStatement) Pos() token.Position {
+	return ls.Token.Pos
+}
+func (ls *LetStatement) End() token.Position {
+	if ls.Value != nil {
+		return ls.Value.End()
+	}
+	if ls.Name != nil {
+		return ls.Name.End()
+	}
+	return ls.Token.End
+}
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
@@ -85,6 +112,18 @@ func (vs *VarStatement) statementNode() {}
 func (vs *VarStatement) TokenLiteral() string {
 	return vs.Token.Literal
 }
+func (vs *VarStatement) Pos() token.Position {
+	return vs.Token.Pos
+}
+func (vs *VarStatement) End() token.Position {
+	if vs.Value != nil {
+		return vs.Value.End()
+	}
+	if vs.Name != nil {
+		return vs.Name.End()
+	}
+	return vs.Token.End
+}
 func (vs *VarStatement) String() string {
 	var out bytes.Buffer
 
@@ -104,21 +143,42 @@ func (vs *VarStatement) String() string {
 // AssignExpression
 //====================================
 type AssignExpression struct {
-	Token token.Token
-	Name  *Identifier
-	Value Expression
+	Token    token.Token // the assignment operator
+	Name     *Identifier // the variable assigned to, or the variable Index is rooted at
+	Index    *IndexExpression
+	Operator string // "=", or "+=" etc. for compound assignment
+	Value    Expression
 }
 
 func (as *AssignExpression) expressionNode() {}
 func (as *AssignExpression) TokenLiteral() string {
 	return as.Token.Literal
 }
+func (as *AssignExpression) Pos() token.Position {
+	if as.Index != nil {
+		return as.Index.Pos()
+	}
+	if as.Name != nil {
+		return as.Name.Pos()
+	}
+	return as.Token.Pos
+}
+func (as *AssignExpression) End() token.Position {
+	if as.Value != nil {
+		return as.Value.End()
+	}
+	return as.Token.End
+}
 func (as *AssignExpression) String() string {
 	var out bytes.Buffer
 
 	out.WriteString("(")
-	out.WriteString(as.Name.String())
-	out.WriteString(" = ")
+	if as.Index != nil {
+		out.WriteString(as.Index.String())
+	} else {
+		out.WriteString(as.Name.String())
+	}
+	out.WriteString(" " + as.Operator + " ")
 	out.WriteString(as.Value.String())
 	out.WriteString(")")
 
@@ -137,6 +197,15 @@ func (rs *ReturnStatement) statementNode() {}
 func (rs *ReturnStatement) TokenLiteral() string {
 	return rs.Token.Literal
 }
+func (rs *ReturnStatement) Pos() token.Position {
+	return rs.Token.Pos
+}
+func (rs *ReturnStatement) End() token.Position {
+	if rs.ReturnValue != nil {
+		return rs.ReturnValue.End()
+	}
+	return rs.Token.End
+}
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
 
@@ -154,14 +223,27 @@ func (rs *ReturnStatement) String() string {
 // BlockStatement
 //====================================
 type BlockStatement struct {
-	Token      token.Token
+	Token      token.Token // token.LBRACE
 	Statements []Statement
+	Rbrace     token.Token
 }
 
 func (bs *BlockStatement) statementNode() {}
 func (bs *BlockStatement) TokenLiteral() string {
 	return bs.Token.Literal
 }
+func (bs *BlockStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+func (bs *BlockStatement) End() token.Position {
+	if bs.Rbrace.End.IsValid() {
+		return bs.Rbrace.End
+	}
+	if len(bs.Statements) > 0 {
+		return bs.Statements[len(bs.Statements)-1].End()
+	}
+	return bs.Token.End
+}
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 
@@ -184,6 +266,18 @@ func (es *ExpressionStatement) statementNode() {}
 func (es *ExpressionStatement) TokenLiteral() string {
 	return es.Token.Literal
 }
+func (es *ExpressionStatement) Pos() token.Position {
+	if es.Expression != nil {
+		return es.Expression.Pos()
+	}
+	return es.Token.Pos
+}
+func (es *ExpressionStatement) End() token.Position {
+	if es.Expression != nil {
+		return es.Expression.End()
+	}
+	return es.Token.End
+}
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
 		return es.Expression.String()
@@ -203,6 +297,12 @@ func (i *Identifier) expressionNode() {}
 func (i *Identifier) TokenLiteral() string {
 	return i.Token.Literal
 }
+func (i *Identifier) Pos() token.Position {
+	return i.Token.Pos
+}
+func (i *Identifier) End() token.Position {
+	return i.Token.End
+}
 func (i *Identifier) String() string {
 	return i.Value
 }
@@ -213,16 +313,45 @@ func (i *Identifier) String() string {
 type IntegerLiteral struct {
 	Token token.Token // token.INT
 	Value int64
+	Big   *big.Int // set instead of Value when the literal does not fit int64
 }
 
 func (il *IntegerLiteral) expressionNode() {}
 func (il *IntegerLiteral) TokenLiteral() string {
 	return il.Token.Literal
 }
+func (il *IntegerLiteral) Pos() token.Position {
+	return il.Token.Pos
+}
+func (il *IntegerLiteral) End() token.Position {
+	return il.Token.End
+}
 func (il *IntegerLiteral) String() string {
 	return il.Token.Literal
 }
 
+//====================================
+// FloatLiteral
+//====================================
+type FloatLiteral struct {
+	Token token.Token // token.FLOAT
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode() {}
+func (fl *FloatLiteral) TokenLiteral() string {
+	return fl.Token.Literal
+}
+func (fl *FloatLiteral) Pos() token.Position {
+	return fl.Token.Pos
+}
+func (fl *FloatLiteral) End() token.Position {
+	return fl.Token.End
+}
+func (fl *FloatLiteral) String() string {
+	return fl.Token.Literal
+}
+
 //====================================
 // StringLiteral
 //====================================
@@ -235,10 +364,57 @@ func (sl *StringLiteral) expressionNode() {}
 func (sl *StringLiteral) TokenLiteral() string {
 	return sl.Token.Literal
 }
+func (sl *StringLiteral) Pos() token.Position {
+	return sl.Token.Pos
+}
+func (sl *StringLiteral) End() token.Position {
+	return sl.Token.End
+}
 func (sl *StringLiteral) String() string {
 	return sl.Token.Literal
 }
 
+//====================================
+// InterpolatedString
+//====================================
+// InterpolatedString is a string with embedded expressions, such as
+// "a${x}b". Parts alternates between the text as *StringLiteral, starting and
+// ending with one, and the embedded expressions.
+type InterpolatedString struct {
+	Token    token.Token // token.INTERP_BEGIN
+	Parts    []Expression
+	EndToken token.Token // token.INTERP_END
+}
+
+func (is *InterpolatedString) expressionNode() {}
+func (is *InterpolatedString) TokenLiteral() string {
+	return is.Token.Literal
+}
+func (is *InterpolatedString) Pos() token.Position {
+	return is.Token.Pos
+}
+func (is *InterpolatedString) End() token.Position {
+	if is.EndToken.End.IsValid() {
+		return is.EndToken.End
+	}
+	return is.Token.End
+}
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("\"")
+	for i, part := range is.Parts {
+		if i%2 == 0 {
+			out.WriteString(part.String())
+		} else {
+			out.WriteString("${" + part.String() + "}")
+		}
+	}
+	out.WriteString("\"")
+
+	return out.String()
+}
+
 //====================================
 // Boolean
 //====================================
@@ -251,6 +427,12 @@ func (b *Boolean) expressionNode() {}
 func (b *Boolean) TokenLiteral() string {
 	return b.Token.Literal
 }
+func (b *Boolean) Pos() token.Position {
+	return b.Token.Pos
+}
+func (b *Boolean) End() token.Position {
+	return b.Token.End
+}
 func (b *Boolean) String() string {
 	return b.Token.Literal
 }
@@ -268,6 +450,15 @@ func (pe *PrefixExpression) expressionNode() {}
 func (pe *PrefixExpression) TokenLiteral() string {
 	return pe.Token.Literal
 }
+func (pe *PrefixExpression) Pos() token.Position {
+	return pe.Token.Pos
+}
+func (pe *PrefixExpression) End() token.Position {
+	if pe.Right != nil {
+		return pe.Right.End()
+	}
+	return pe.Token.End
+}
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -292,6 +483,18 @@ func (ie *InfixExpression) expressionNode() {}
 func (ie *InfixExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
+func (ie *InfixExpression) Pos() token.Position {
+	if ie.Left != nil {
+		return ie.Left.Pos()
+	}
+	return ie.Token.Pos
+}
+func (ie *InfixExpression) End() token.Position {
+	if ie.Right != nil {
+		return ie.Right.End()
+	}
+	return ie.Token.End
+}
 func (ie *InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -303,6 +506,42 @@ func (ie *InfixExpression) String() string {
 	return out.String()
 }
 
+//====================================
+// RangeExpression
+//====================================
+type RangeExpression struct {
+	Token token.Token // token.RANGE
+	From  Expression
+	To    Expression // exclusive
+}
+
+func (re *RangeExpression) expressionNode() {}
+func (re *RangeExpression) TokenLiteral() string {
+	return re.Token.Literal
+}
+func (re *RangeExpression) Pos() token.Position {
+	if re.From != nil {
+		return re.From.Pos()
+	}
+	return re.Token.Pos
+}
+func (re *RangeExpression) End() token.Position {
+	if re.To != nil {
+		return re.To.End()
+	}
+	return re.Token.End
+}
+func (re *RangeExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(re.From.String())
+	out.WriteString("..")
+	out.WriteString(re.To.String())
+	out.WriteString(")")
+	return out.String()
+}
+
 //====================================
 // IfExpression
 //====================================
@@ -317,6 +556,18 @@ func (ie *IfExpression) expressionNode() {}
 func (ie *IfExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
+func (ie *IfExpression) Pos() token.Position {
+	return ie.Token.Pos
+}
+func (ie *IfExpression) End() token.Position {
+	if ie.Alternative != nil {
+		return ie.Alternative.End()
+	}
+	if ie.Consequence != nil {
+		return ie.Consequence.End()
+	}
+	return ie.Token.End
+}
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -338,7 +589,10 @@ func (ie *IfExpression) String() string {
 //====================================
 type FunctionLiteral struct {
 	Token      token.Token
+	Name       string // name of the binding the literal is assigned to by let or var
 	Parameters []*Identifier
+	Defaults   []Expression // default value of each parameter, nil for required ones
+	Rest       *Identifier  // parameter collecting the remaining arguments, or nil
 	Body       *BlockStatement
 }
 
@@ -346,12 +600,28 @@ func (fl *FunctionLiteral) expressionNode() {}
 func (fl *FunctionLiteral) TokenLiteral() string {
 	return fl.Token.Literal
 }
+func (fl *FunctionLiteral) Pos() token.Position {
+	return fl.Token.Pos
+}
+func (fl *FunctionLiteral) End() token.Position {
+	if fl.Body != nil {
+		return fl.Body.End()
+	}
+	return fl.Token.End
+}
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
 	params := []string{}
-	for _, p := range fl.Parameters {
-		params = append(params, p.String())
+	for i, p := range fl.Parameters {
+		if i < len(fl.Defaults) && fl.Defaults[i] != nil {
+			params = append(params, p.String()+" = "+fl.Defaults[i].String())
+		} else {
+			params = append(params, p.String())
+		}
+	}
+	if fl.Rest != nil {
+		params = append(params, "..."+fl.Rest.String())
 	}
 
 	out.WriteString(fl.TokenLiteral())
@@ -367,15 +637,29 @@ func (fl *FunctionLiteral) String() string {
 // CallExpression
 //====================================
 type CallExpression struct {
-	Token     token.Token
-	Function  Expression
-	Arguments []Expression
+	Token          token.Token // token.LPAREN
+	Function       Expression
+	Arguments      []Expression
+	NamedArguments []*NamedArgument
+	Rparen         token.Token
 }
 
 func (ce *CallExpression) expressionNode() {}
 func (ce *CallExpression) TokenLiteral() string {
 	return ce.Token.Literal
 }
+func (ce *CallExpression) Pos() token.Position {
+	if ce.Function != nil {
+		return ce.Function.Pos()
+	}
+	return ce.Token.Pos
+}
+func (ce *CallExpression) End() token.Position {
+	if ce.Rparen.End.IsValid() {
+		return ce.Rparen.End
+	}
+	return ce.Token.End
+}
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 
@@ -383,6 +667,9 @@ func (ce *CallExpression) String() string {
 	for _, p := range ce.Arguments {
 		params = append(params, p.String())
 	}
+	for _, na := range ce.NamedArguments {
+		params = append(params, na.String())
+	}
 
 	out.WriteString(ce.Function.String())
 	out.WriteString("(")
@@ -392,18 +679,53 @@ func (ce *CallExpression) String() string {
 	return out.String()
 }
 
+//====================================
+// NamedArgument
+//====================================
+// NamedArgument is a "name: value" argument of a call.
+type NamedArgument struct {
+	Name  *Identifier
+	Value Expression
+}
+
+func (na *NamedArgument) TokenLiteral() string {
+	return na.Name.TokenLiteral()
+}
+func (na *NamedArgument) Pos() token.Position {
+	return na.Name.Pos()
+}
+func (na *NamedArgument) End() token.Position {
+	if na.Value != nil {
+		return na.Value.End()
+	}
+	return na.Name.End()
+}
+func (na *NamedArgument) String() string {
+	return na.Name.String() + ": " + na.Value.String()
+}
+
 //====================================
 // ArrayLiteral
 //====================================
 type ArrayLiteral struct {
-	Token    token.Token
+	Token    token.Token // token.LBRACKET
 	Elements []Expression
+	Rbracket token.Token
 }
 
 func (al *ArrayLiteral) expressionNode() {}
 func (al *ArrayLiteral) TokenLiteral() string {
 	return al.Token.Literal
 }
+func (al *ArrayLiteral) Pos() token.Position {
+	return al.Token.Pos
+}
+func (al *ArrayLiteral) End() token.Position {
+	if al.Rbracket.End.IsValid() {
+		return al.Rbracket.End
+	}
+	return al.Token.End
+}
 func (al *ArrayLiteral) String() string {
 	var out bytes.Buffer
 
@@ -423,15 +745,28 @@ func (al *ArrayLiteral) String() string {
 // IndexExpression
 //====================================
 type IndexExpression struct {
-	Token token.Token
-	Left  Expression
-	Index Expression
+	Token    token.Token // token.LBRACKET
+	Left     Expression
+	Index    Expression
+	Rbracket token.Token
 }
 
 func (ie *IndexExpression) expressionNode() {}
 func (ie *IndexExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
+func (ie *IndexExpression) Pos() token.Position {
+	if ie.Left != nil {
+		return ie.Left.Pos()
+	}
+	return ie.Token.Pos
+}
+func (ie *IndexExpression) End() token.Position {
+	if ie.Rbracket.End.IsValid() {
+		return ie.Rbracket.End
+	}
+	return ie.Token.End
+}
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 
@@ -444,17 +779,75 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+//====================================
+// SliceExpression
+//====================================
+type SliceExpression struct {
+	Token    token.Token // token.LBRACKET
+	Left     Expression
+	Low      Expression // nil when omitted, as are High and Step
+	High     Expression
+	Step     Expression
+	Rbracket token.Token
+}
+
+func (se *SliceExpression) expressionNode() {}
+func (se *SliceExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+func (se *SliceExpression) Pos() token.Position {
+	if se.Left != nil {
+		return se.Left.Pos()
+	}
+	return se.Token.Pos
+}
+func (se *SliceExpression) End() token.Position {
+	if se.Rbracket.End.IsValid() {
+		return se.Rbracket.End
+	}
+	return se.Token.End
+}
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	if se.Low != nil {
+		out.WriteString(se.Low.String())
+	}
+	out.WriteString(":")
+	if se.High != nil {
+		out.WriteString(se.High.String())
+	}
+	if se.Step != nil {
+		out.WriteString(":")
+		out.WriteString(se.Step.String())
+	}
+	out.WriteString("])")
+
+	return out.String()
+}
+
 //====================================
 // HashLiteral
 //====================================
 
 type HashLiteral struct {
-	Token token.Token
-	Pairs map[Expression]Expression
+	Token  token.Token // token.LBRACE
+	Pairs  map[Expression]Expression
+	Rbrace token.Token
 }
 
 func (hl *HashLiteral) expressionNode()      {}
 func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+func (hl *HashLiteral) Pos() token.Position  { return hl.Token.Pos }
+func (hl *HashLiteral) End() token.Position {
+	if hl.Rbrace.End.IsValid() {
+		return hl.Rbrace.End
+	}
+	return hl.Token.End
+}
 func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 
@@ -485,18 +878,196 @@ func (fs *ForStatement) statementNode() {}
 func (fs *ForStatement) TokenLiteral() string {
 	return fs.Token.Literal
 }
+func (fs *ForStatement) Pos() token.Position {
+	return fs.Token.Pos
+}
+func (fs *ForStatement) End() token.Position {
+	if fs.Block != nil {
+		return fs.Block.End()
+	}
+	return fs.Token.End
+}
 func (fs *ForStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(fs.TokenLiteral() + " (")
-	out.WriteString(fs.InitialStatement.String())
-	out.WriteString(" ")
-	out.WriteString(fs.Condition.String())
+	if fs.InitialStatement != nil {
+		out.WriteString(strings.TrimSuffix(fs.InitialStatement.String(), ";"))
+	}
+	out.WriteString("; ")
+	if fs.Condition != nil {
+		out.WriteString(fs.Condition.String())
+	}
 	out.WriteString("; ")
-	out.WriteString(fs.PostStatement.String())
+	if fs.PostStatement != nil {
+		out.WriteString(fs.PostStatement.String())
+	}
 	out.WriteString(") { ")
 	out.WriteString(fs.Block.String())
 	out.WriteString(" }")
 
 	return out.String()
 }
+
+//====================================
+// ForInStatement
+//====================================
+type ForInStatement struct {
+	Token    token.Token // token.FOR
+	Key      *Identifier // nil when only the value is bound
+	Value    *Identifier
+	Iterable Expression
+	Block    *BlockStatement
+}
+
+func (fs *ForInStatement) statementNode() {}
+func (fs *ForInStatement) TokenLiteral() string {
+	return fs.Token.Literal
+}
+func (fs *ForInStatement) Pos() token.Position {
+	return fs.Token.Pos
+}
+func (fs *ForInStatement) End() token.Position {
+	if fs.Block != nil {
+		return fs.Block.End()
+	}
+	return fs.Token.End
+}
+func (fs *ForInStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(fs.TokenLiteral() + " (")
+	if fs.Key != nil {
+		out.WriteString(fs.Key.String() + ", ")
+	}
+	out.WriteString(fs.Value.String())
+	out.WriteString(" in ")
+	out.WriteString(fs.Iterable.String())
+	out.WriteString(") { ")
+	out.WriteString(fs.Block.String())
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+//====================================
+// WhileStatement
+//====================================
+type WhileStatement struct {
+	Token     token.Token // token.WHILE
+	Condition Expression
+	Block     *BlockStatement
+}
+
+func (ws *WhileStatement) statementNode() {}
+func (ws *WhileStatement) TokenLiteral() string {
+	return ws.Token.Literal
+}
+func (ws *WhileStatement) Pos() token.Position {
+	return ws.Token.Pos
+}
+func (ws *WhileStatement) End() token.Position {
+	if ws.Block != nil {
+		return ws.Block.End()
+	}
+	return ws.Token.End
+}
+func (ws *WhileStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ws.TokenLiteral() + " (")
+	out.WriteString(ws.Condition.String())
+	out.WriteString(") { ")
+	out.WriteString(ws.Block.String())
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+//====================================
+// BreakStatement
+//====================================
+type BreakStatement struct {
+	Token token.Token // token.BREAK
+}
+
+func (bs *BreakStatement) statementNode() {}
+func (bs *BreakStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+func (bs *BreakStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+func (bs *BreakStatement) End() token.Position {
+	return bs.Token.End
+}
+func (bs *BreakStatement) String() string {
+	return bs.TokenLiteral() + ";"
+}
+
+//====================================
+// ContinueStatement
+//====================================
+type ContinueStatement struct {
+	Token token.Token // token.CONTINUE
+}
+
+func (cs *ContinueStatement) statementNode() {}
+func (cs *ContinueStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+func (cs *ContinueStatement) Pos() token.Position {
+	return cs.Token.Pos
+}
+func (cs *ContinueStatement) End() token.Position {
+	return cs.Token.End
+}
+func (cs *ContinueStatement) String() string {
+	return cs.TokenLiteral() + ";"
+}
+
+//====================================
+// BadStatement
+//====================================
+// BadStatement is a placeholder for a statement containing syntax errors.
+type BadStatement struct {
+	Token token.Token // first token of the statement
+	To    token.Position
+}
+
+func (bs *BadStatement) statementNode() {}
+func (bs *BadStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+func (bs *BadStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+func (bs *BadStatement) End() token.Position {
+	return bs.To
+}
+func (bs *BadStatement) String() string {
+	return "<bad statement>"
+}
+
+//====================================
+// BadExpression
+//====================================
+// BadExpression is a placeholder for an expression containing syntax errors.
+type BadExpression struct {
+	Token token.Token // first token of the expression
+	To    token.Position
+}
+
+func (be *BadExpression) expressionNode() {}
+func (be *BadExpression) TokenLiteral() string {
+	return be.Token.Literal
+}
+func (be *BadExpression) Pos() token.Position {
+	return be.Token.Pos
+}
+func (be *BadExpression) End() token.Position {
+	return be.To
+}
+func (be *BadExpression) String() string {
+	return "<bad expression>"
+}
@@ -0,0 +1,171 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+	"monkey/object"
+)
+
+// Overflow selects what an integer operation does when its result does not
+// fit int64.
+type Overflow int
+
+const (
+	// PromoteToBigInt continues with an arbitrary-precision integer.
+	PromoteToBigInt Overflow = iota
+	// ReportOverflow returns an "integer overflow" error. Bitwise operators
+	// and shifts wrap around.
+	ReportOverflow
+	// WrapAround returns the wrapped-around int64 result.
+	WrapAround
+)
+
+// IntegerOverflow is the behavior of integer operations that overflow int64.
+var IntegerOverflow = PromoteToBigInt
+
+// maxPowBits bounds the size of a ** result. Exponentiation takes far longer
+// than a shift of the same size, hence the lower limit.
+const maxPowBits = 1 << 24
+
+// evalBigIntegerInfixExpression evaluates an operation on two integers with
+// arbitrary precision. The result is normalized back to an Integer when it fits.
+func evalBigIntegerInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftVal := toBig(left)
+	rightVal := toBig(right)
+	result := new(big.Int)
+
+	switch operator {
+	case "+":
+		result.Add(leftVal, rightVal)
+	case "-":
+		result.Sub(leftVal, rightVal)
+	case "*":
+		result.Mul(leftVal, rightVal)
+	case "/":
+		if rightVal.Sign() == 0 {
+			return newError("division by zero")
+		}
+		result.Quo(leftVal, rightVal)
+	case "%":
+		if rightVal.Sign() == 0 {
+			return newError("modulo by zero")
+		}
+		result.Rem(leftVal, rightVal)
+	case "**":
+		if rightVal.Sign() < 0 {
+			return &object.Float{Value: math.Pow(toFloat(left), toFloat(right))}
+		}
+		// the result has at most exponent * (bit length of base) bits;
+		// 0, 1 and -1 stay small for any exponent
+		if bits := int64(leftVal.BitLen()); bits > 1 {
+			if !rightVal.IsInt64() || rightVal.Int64() > maxPowBits/bits {
+				return newError("exponent too large: %s", rightVal)
+			}
+		}
+		result.Exp(leftVal, rightVal, nil)
+	case "&":
+		result.And(leftVal, rightVal)
+	case "|":
+		result.Or(leftVal, rightVal)
+	case "^":
+		result.Xor(leftVal, rightVal)
+	case "<<", ">>":
+		if rightVal.Sign() < 0 {
+			return newError("negative shift count: %s", rightVal)
+		}
+		if !rightVal.IsInt64() || rightVal.Int64() > math.MaxInt32 {
+			return newError("shift count too large: %s", rightVal)
+		}
+		if operator == "<<" {
+			result.Lsh(leftVal, uint(rightVal.Int64()))
+		} else {
+			result.Rsh(leftVal, uint(rightVal.Int64()))
+		}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) <= 0)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) >= 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+
+	return normalizeInteger(result)
+}
+
+// normalizeInteger returns value as an Integer when it fits int64, and as a BigInt otherwise.
+func normalizeInteger(value *big.Int) object.Object {
+	if value.IsInt64() {
+		return &object.Integer{Value: value.Int64()}
+	}
+	return &object.BigInt{Value: value}
+}
+
+func toBig(obj object.Object) *big.Int {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return big.NewInt(obj.Value)
+	case *object.BigInt:
+		return obj.Value
+	default:
+		return new(big.Int)
+	}
+}
+
+// The functions below return the wrapped-around result, and whether it is exact.
+
+func addInt(a int64, b int64) (int64, bool) {
+	c := a + b
+	return c, (c > a) == (b > 0)
+}
+
+func subInt(a int64, b int64) (int64, bool) {
+	c := a - b
+	return c, (c < a) == (b > 0)
+}
+
+func mulInt(a int64, b int64) (int64, bool) {
+	c := a * b
+	if a == 0 {
+		return c, true
+	}
+	return c, c/a == b && !(a == -1 && b == math.MinInt64)
+}
+
+func divInt(a int64, b int64) (int64, bool) {
+	return a / b, !(a == math.MinInt64 && b == -1)
+}
+
+func negInt(a int64) (int64, bool) {
+	return -a, a != math.MinInt64
+}
+
+func shlInt(a int64, n int64) (int64, bool) {
+	c := a << uint64(n)
+	return c, n < 64 && c>>uint64(n) == a
+}
+
+// powInt computes base**exp for exp >= 0 by repeated squaring.
+func powInt(base int64, exp int64) (int64, bool) {
+	result, exact := int64(1), true
+	for {
+		var ok bool
+		if exp&1 == 1 {
+			result, ok = mulInt(result, base)
+			exact = exact && ok
+		}
+		exp >>= 1
+		if exp == 0 {
+			return result, exact
+		}
+		base, ok = mulInt(base, base)
+		exact = exact && ok
+	}
+}
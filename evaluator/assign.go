@@ -0,0 +1,121 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+	"strings"
+)
+
+// evalAssignExpression assigns to a mutable variable, or to an element of an
+// array or hash the variable holds. Compound operators such as += combine the
+// current value with the assigned one first.
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	identVal, ok := env.Get(node.Name.Value)
+	if !ok {
+		return newError("identifier not found: %s", node.Name.Value)
+	}
+	if !identVal.IsMutable {
+		return newError("can't assign value to immutable identifier: %s", node.Name.Value)
+	}
+
+	if node.Index == nil {
+		val := evalAssignedValue(node, env)
+		if isAbrupt(val) {
+			return val
+		}
+		if node.Operator != "=" {
+			val = evalInfixExpression(strings.TrimSuffix(node.Operator, "="), identVal.Obj, val)
+			if isAbrupt(val) {
+				return val
+			}
+		}
+		env.Assign(node.Name.Value, val)
+		return val
+	}
+
+	container := Eval(node.Index.Left, env)
+	if isAbrupt(container) {
+		return container
+	}
+	index := Eval(node.Index.Index, env)
+	if isAbrupt(index) {
+		return index
+	}
+	val := evalAssignedValue(node, env)
+	if isAbrupt(val) {
+		return val
+	}
+
+	if node.Operator != "=" {
+		current := getElement(container, index)
+		if isAbrupt(current) {
+			return current
+		}
+		val = evalInfixExpression(strings.TrimSuffix(node.Operator, "="), current, val)
+		if isAbrupt(val) {
+			return val
+		}
+	}
+
+	if err := setElement(container, index, val); err != nil {
+		return err
+	}
+	return val
+}
+
+// evalAssignedValue evaluates the right-hand side of an assignment, which is
+// NULL when it produces no value.
+func evalAssignedValue(node *ast.AssignExpression, env *object.Environment) object.Object {
+	val := Eval(node.Value, env)
+	if val == nil {
+		return NULL
+	}
+	return val
+}
+
+// getElement is like evalIndexExpression, but reports missing elements as errors.
+func getElement(container object.Object, index object.Object) object.Object {
+	switch container := container.(type) {
+	case *object.Array:
+		i, ok := normalizeIndex(index, len(container.Elements))
+		if !ok {
+			return newError("index out of range: %s", index.Inspect())
+		}
+		return container.Elements[i]
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+		pair, ok := container.Pairs[key.HashKey()]
+		if !ok {
+			return newError("key not found: %s", index.Inspect())
+		}
+		return pair.Value
+	default:
+		return newError("index operator not supported: %s", container.Type())
+	}
+}
+
+// setElement stores val in an array or hash in place. A hash gains the key if
+// it is missing, but an array index must be in range.
+func setElement(container object.Object, index object.Object, val object.Object) *object.Error {
+	switch container := container.(type) {
+	case *object.Array:
+		i, ok := normalizeIndex(index, len(container.Elements))
+		if !ok {
+			return newError("index out of range: %s", index.Inspect())
+		}
+		container.Elements[i] = val
+		return nil
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+		container.Pairs[key.HashKey()] = object.HashPair{Key: index, Value: val}
+		return nil
+	default:
+		return newError("index assignment not supported: %s", container.Type())
+	}
+}
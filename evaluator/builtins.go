@@ -2,19 +2,22 @@ package evaluator
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 	"monkey/object"
+	"sort"
+	"strconv"
+	"unicode/utf8"
 )
 
 var builtins = map[string]*object.Builtin{
 	"len": &object.Builtin{
+		MinArgs: 1,
+		MaxArgs: 1,
 		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
 			switch arg := args[0].(type) {
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
 			case *object.Array:
 				return &object.Integer{Value: int64(len(arg.Elements))}
 			default:
@@ -24,11 +27,9 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"first": &object.Builtin{
+		MinArgs: 1,
+		MaxArgs: 1,
 		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
 			if args[0].Type() != object.ARRAY_OBJ {
 				return newError("arguments to `first` must be ARRAY, got %s", args[0].Type())
 			}
@@ -42,11 +43,9 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"last": &object.Builtin{
+		MinArgs: 1,
+		MaxArgs: 1,
 		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
 			if args[0].Type() != object.ARRAY_OBJ {
 				return newError("arguments to `last` must be ARRAY, got %s", args[0].Type())
 			}
@@ -61,11 +60,9 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"rest": &object.Builtin{
+		MinArgs: 1,
+		MaxArgs: 1,
 		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
 			if args[0].Type() != object.ARRAY_OBJ {
 				return newError("arguments to `rest` must be ARRAY, got %s", args[0].Type())
 			}
@@ -82,11 +79,9 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"push": &object.Builtin{
+		MinArgs: 2,
+		MaxArgs: 2,
 		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 2 {
-				return newError("wrong number of arguments. got=%d, want=2", len(args))
-			}
-
 			if args[0].Type() != object.ARRAY_OBJ {
 				return newError("arguments to `rest` must be ARRAY, got %s", args[0].Type())
 			}
@@ -100,6 +95,8 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"puts": &object.Builtin{
+		MinArgs: 0,
+		MaxArgs: -1,
 		Fn: func(args ...object.Object) object.Object {
 			for _, arg := range args {
 				fmt.Println(arg.Inspect())
@@ -108,4 +105,112 @@ var builtins = map[string]*object.Builtin{
 			return NULL
 		},
 	},
+	"int": &object.Builtin{
+		MinArgs: 1,
+		MaxArgs: 1,
+		Fn: func(args ...object.Object) object.Object {
+			switch arg := args[0].(type) {
+			case *object.Integer, *object.BigInt:
+				return arg
+			case *object.Float:
+				if math.IsNaN(arg.Value) || math.IsInf(arg.Value, 0) {
+					return newError("cannot convert %s to INTEGER", arg.Inspect())
+				}
+				value, _ := big.NewFloat(arg.Value).Int(nil)
+				return normalizeInteger(value)
+			case *object.String:
+				value, ok := new(big.Int).SetString(arg.Value, 10)
+				if !ok {
+					return newError("could not parse %q as integer", arg.Value)
+				}
+				return normalizeInteger(value)
+			default:
+				return newError("argument to `int` not supported, got %s", args[0].Type())
+			}
+		},
+	},
+	"float": &object.Builtin{
+		MinArgs: 1,
+		MaxArgs: 1,
+		Fn: func(args ...object.Object) object.Object {
+			switch arg := args[0].(type) {
+			case *object.Integer, *object.BigInt:
+				return &object.Float{Value: toFloat(arg)}
+			case *object.Float:
+				return arg
+			case *object.String:
+				value, err := strconv.ParseFloat(arg.Value, 64)
+				if err != nil {
+					return newError("could not parse %q as float", arg.Value)
+				}
+				return &object.Float{Value: value}
+			default:
+				return newError("argument to `float` not supported, got %s", args[0].Type())
+			}
+		},
+	},
+	"bytes": &object.Builtin{
+		MinArgs: 1,
+		MaxArgs: 1,
+		Fn: func(args ...object.Object) object.Object {
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `bytes` must be STRING, got %s", args[0].Type())
+			}
+
+			elements := make([]object.Object, len(str.Value))
+			for i := 0; i < len(str.Value); i++ {
+				elements[i] = &object.Integer{Value: int64(str.Value[i])}
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+	"runes": &object.Builtin{
+		MinArgs: 1,
+		MaxArgs: 1,
+		Fn: func(args ...object.Object) object.Object {
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `runes` must be STRING, got %s", args[0].Type())
+			}
+
+			elements := []object.Object{}
+			for _, ch := range str.Value {
+				elements = append(elements, &object.Integer{Value: int64(ch)})
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+	"floor": roundingBuiltin("floor", math.Floor),
+	"ceil":  roundingBuiltin("ceil", math.Ceil),
+	"round": roundingBuiltin("round", math.Round),
+}
+
+// roundingBuiltin returns a builtin applying round to a float.
+// Integers are returned unchanged.
+func roundingBuiltin(name string, round func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		MinArgs: 1,
+		MaxArgs: 1,
+		Fn: func(args ...object.Object) object.Object {
+			switch arg := args[0].(type) {
+			case *object.Integer, *object.BigInt:
+				return arg
+			case *object.Float:
+				return &object.Float{Value: round(arg.Value)}
+			default:
+				return newError("argument to `%s` must be INTEGER or FLOAT, got %s", name, args[0].Type())
+			}
+		},
+	}
+}
+
+// BuiltinNames returns the names of the builtin functions in sorted order.
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
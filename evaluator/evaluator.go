@@ -1,7 +1,10 @@
 package evaluator
 
 import (
+	"bytes"
 	"fmt"
+	"math"
+	"math/big"
 	"monkey/ast"
 	"monkey/object"
 )
@@ -10,59 +13,102 @@ var (
 	NULL  = &object.Null{}
 	TRUE  = &object.Boolean{Value: true}
 	FALSE = &object.Boolean{Value: false}
+
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
 )
 
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	result := eval(node, env)
+
+	// errors are attributed to the innermost node they were produced by
+	if err, ok := result.(*object.Error); ok && !err.Pos.IsValid() {
+		err.Pos, err.End = node.Pos(), node.End()
+	}
+
+	return result
+}
+
+func eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
 		return evalProgram(node, env)
 	case *ast.ExpressionStatement:
 		return Eval(node.Expression, env)
 	case *ast.IntegerLiteral:
+		if node.Big != nil {
+			return &object.BigInt{Value: node.Big}
+		}
 		return &object.Integer{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
+	case *ast.InterpolatedString:
+		return evalInterpolatedString(node, env)
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
-		if isError(right) {
+		if isAbrupt(right) {
 			return right
 		}
 		return evalPrefixExpression(node.Operator, right)
 	case *ast.InfixExpression:
 		left := Eval(node.Left, env)
-		if isError(left) {
+		if isAbrupt(left) {
 			return left
 		}
 
+		if node.Operator == "&&" || node.Operator == "||" {
+			return evalLogicalExpression(node, left, env)
+		}
+
 		right := Eval(node.Right, env)
-		if isError(right) {
+		if isAbrupt(right) {
 			return right
 		}
 
 		return evalInfixExpression(node.Operator, left, right)
+	case *ast.RangeExpression:
+		from := Eval(node.From, env)
+		if isAbrupt(from) {
+			return from
+		}
+		to := Eval(node.To, env)
+		if isAbrupt(to) {
+			return to
+		}
+		return evalRangeExpression(from, to)
 	case *ast.BlockStatement:
 		return evalBlockStatements(node, env)
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
 	case *ast.ForStatement:
 		return evalForStatement(node, env)
+	case *ast.ForInStatement:
+		return evalForInStatement(node, env)
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, env)
+	case *ast.BreakStatement:
+		return BREAK
+	case *ast.ContinueStatement:
+		return CONTINUE
 	case *ast.ReturnStatement:
 		val := Eval(node.ReturnValue, env)
-		if isError(val) {
+		if isAbrupt(val) {
 			return val
 		}
 		return &object.ReturnValue{Value: val}
 	case *ast.LetStatement:
 		val := Eval(node.Value, env)
-		if isError(val) {
+		if isAbrupt(val) {
 			return val
 		}
 		env.Set(node.Name.Value, val, false)
 	case *ast.VarStatement:
 		val := Eval(node.Value, env)
-		if isError(val) {
+		if isAbrupt(val) {
 			return val
 		}
 		env.Set(node.Name.Value, val, true)
@@ -72,24 +118,31 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		params := node.Parameters
 		body := node.Body
 		return &object.Function{
+			Name:       node.Name,
 			Parameters: params,
+			Defaults:   node.Defaults,
+			Rest:       node.Rest,
 			Body:       body,
 			Env:        env,
 		}
 	case *ast.CallExpression:
 		function := Eval(node.Function, env)
-		if isError(function) {
+		if isAbrupt(function) {
 			return function
 		}
 		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
+		if len(args) == 1 && isAbrupt(args[0]) {
 			return args[0]
 		}
+		named, err := evalNamedArguments(node.NamedArguments, env)
+		if err != nil {
+			return err
+		}
 
-		return applyFunction(function, args)
+		return applyFunction(function, args, named, node)
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
-		if len(elements) == 1 && isError(elements[0]) {
+		if len(elements) == 1 && isAbrupt(elements[0]) {
 			return elements[0]
 		}
 		return &object.Array{Elements: elements}
@@ -97,28 +150,22 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalHashLiteral(node, env)
 	case *ast.IndexExpression:
 		left := Eval(node.Left, env)
-		if isError(left) {
+		if isAbrupt(left) {
 			return left
 		}
 		index := Eval(node.Index, env)
-		if isError(index) {
+		if isAbrupt(index) {
 			return index
 		}
 		return evalIndexExpression(left, index)
+	case *ast.SliceExpression:
+		return evalSliceExpression(node, env)
 	case *ast.AssignExpression:
-		identVal, ok := env.Get(node.Name.Value)
-		if !ok {
-			return newError("identifier not found: " + node.Name.Value)
-		}
-		if !identVal.IsMutable {
-			return newError("can't assign value to immutable identifier: " + node.Name.Value)
-		}
-		val := Eval(node.Value, env)
-		if isError(val) {
-			return val
-		}
-		env.Set(node.Name.Value, val, true)
-		return val
+		return evalAssignExpression(node, env)
+	case *ast.BadStatement:
+		return newError("malformed statement")
+	case *ast.BadExpression:
+		return newError("malformed expression")
 	}
 
 	return nil
@@ -151,7 +198,7 @@ func evalBlockStatements(block *ast.BlockStatement, env *object.Environment) obj
 
 		if result != nil {
 			rt := result.Type()
-			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ || rt == object.BREAK_OBJ || rt == object.CONTINUE_OBJ {
 				return result
 			}
 		}
@@ -173,6 +220,8 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 		return evalBangOperatorExpression(right)
 	case "-":
 		return evalMinusPrefixOperatorExpression(right)
+	case "~":
+		return evalBitwiseNotOperatorExpression(right)
 	default:
 		return newError("unknown operator: %s%s", operator, right.Type())
 	}
@@ -192,18 +241,44 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 }
 
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
+	switch right := right.(type) {
+	case *object.Integer:
+		value, exact := negInt(right.Value)
+		if !exact {
+			switch IntegerOverflow {
+			case PromoteToBigInt:
+				return normalizeInteger(new(big.Int).Neg(toBig(right)))
+			case ReportOverflow:
+				return newError("integer overflow")
+			}
+		}
+		return &object.Integer{Value: value}
+	case *object.BigInt:
+		return normalizeInteger(new(big.Int).Neg(right.Value))
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
 		return newError("unknown operator: -%s", right.Type())
 	}
+}
 
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
+func evalBitwiseNotOperatorExpression(right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Integer:
+		return &object.Integer{Value: ^right.Value}
+	case *object.BigInt:
+		return normalizeInteger(new(big.Int).Not(right.Value))
+	default:
+		return newError("unknown operator: ~%s", right.Type())
+	}
 }
 
 func evalInfixExpression(operator string, left object.Object, right object.Object) object.Object {
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(operator, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
 	case operator == "==":
@@ -217,23 +292,129 @@ func evalInfixExpression(operator string, left object.Object, right object.Objec
 	}
 }
 
+// evalIntegerInfixExpression evaluates an operation on two integers. Results
+// that do not fit int64 are computed again with arbitrary precision.
 func evalIntegerInfixExpression(operator string, left object.Object, right object.Object) object.Object {
-	leftVal := left.(*object.Integer).Value
-	rightVal := right.(*object.Integer).Value
+	l, lok := left.(*object.Integer)
+	r, rok := right.(*object.Integer)
+	if !lok || !rok {
+		return evalBigIntegerInfixExpression(operator, left, right)
+	}
+	leftVal, rightVal := l.Value, r.Value
+
+	var value int64
+	exact := true
+
+	switch operator {
+	case "+":
+		value, exact = addInt(leftVal, rightVal)
+	case "-":
+		value, exact = subInt(leftVal, rightVal)
+	case "*":
+		value, exact = mulInt(leftVal, rightVal)
+	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		value, exact = divInt(leftVal, rightVal)
+	case "%":
+		if rightVal == 0 {
+			return newError("modulo by zero")
+		}
+		value = leftVal % rightVal
+	case "**":
+		if rightVal < 0 {
+			return &object.Float{Value: math.Pow(float64(leftVal), float64(rightVal))}
+		}
+		value, exact = powInt(leftVal, rightVal)
+	case "&":
+		value = leftVal & rightVal
+	case "|":
+		value = leftVal | rightVal
+	case "^":
+		value = leftVal ^ rightVal
+	case "<<":
+		if rightVal < 0 {
+			return newError("negative shift count: %d", rightVal)
+		}
+		value, exact = shlInt(leftVal, rightVal)
+	case ">>":
+		if rightVal < 0 {
+			return newError("negative shift count: %d", rightVal)
+		}
+		value = leftVal >> uint64(rightVal)
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+
+	if !exact {
+		switch {
+		case IntegerOverflow == PromoteToBigInt:
+			return evalBigIntegerInfixExpression(operator, left, right)
+		case IntegerOverflow == ReportOverflow && operator != "<<":
+			return newError("integer overflow")
+		}
+	}
+	return &object.Integer{Value: value}
+}
+
+// evalLogicalExpression evaluates && and ||. The right operand is only
+// evaluated when the left one does not decide the result.
+func evalLogicalExpression(node *ast.InfixExpression, left object.Object, env *object.Environment) object.Object {
+	if node.Operator == "&&" && !isTruthy(left) {
+		return FALSE
+	}
+	if node.Operator == "||" && isTruthy(left) {
+		return TRUE
+	}
+
+	right := Eval(node.Right, env)
+	if isAbrupt(right) {
+		return right
+	}
+
+	return nativeBoolToBooleanObject(isTruthy(right))
+}
+
+// evalFloatInfixExpression evaluates an operation on two numbers of which at
+// least one is a float. The integer operand is promoted to float.
+func evalFloatInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftVal := toFloat(left)
+	rightVal := toFloat(right)
 
 	switch operator {
 	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
+		return &object.Float{Value: leftVal + rightVal}
 	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
+		return &object.Float{Value: leftVal - rightVal}
 	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
+		return &object.Float{Value: leftVal * rightVal}
 	case "/":
-		return &object.Integer{Value: leftVal / rightVal}
+		return &object.Float{Value: leftVal / rightVal}
+	case "%":
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+	case "**":
+		return &object.Float{Value: math.Pow(leftVal, rightVal)}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 	case ">":
 		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
 	case "==":
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 	case "!=":
@@ -243,6 +424,24 @@ func evalIntegerInfixExpression(operator string, left object.Object, right objec
 	}
 }
 
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.BigInt:
+		f, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return f
+	case *object.Float:
+		return obj.Value
+	default:
+		return 0
+	}
+}
+
 func evalStringInfixExpression(operator string, left object.Object, right object.Object) object.Object {
 	if operator != "+" {
 		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
@@ -256,7 +455,7 @@ func evalStringInfixExpression(operator string, left object.Object, right object
 
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
 	condition := Eval(ie.Condition, env)
-	if isError(condition) {
+	if isAbrupt(condition) {
 		return condition
 	}
 
@@ -271,15 +470,89 @@ func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Obje
 
 func evalForStatement(stmt *ast.ForStatement, env *object.Environment) object.Object {
 	initStmt := Eval(stmt.InitialStatement, env)
-	if isError(initStmt) {
+	if isAbrupt(initStmt) {
 		return initStmt
 	}
 
-	var result object.Object
+	var result object.Object = NULL
+
+	for {
+		if stmt.Condition != nil {
+			condition := Eval(stmt.Condition, env)
+			if isAbrupt(condition) {
+				return condition
+			}
+			if !isTruthy(condition) {
+				break
+			}
+		}
+
+		result = Eval(stmt.Block, env)
+		if isError(result) || isReturn(result) {
+			return result
+		}
+		if result == BREAK {
+			return NULL
+		}
+		if result == CONTINUE || result == nil {
+			result = NULL
+		}
+
+		postStmt := Eval(stmt.PostStatement, env)
+		if isAbrupt(postStmt) {
+			return postStmt
+		}
+	}
+
+	return result
+}
+
+// evalForInStatement runs the block once per element of an object.Iterable.
+// Each iteration binds the loop variables immutably in a scope of its own.
+func evalForInStatement(stmt *ast.ForInStatement, env *object.Environment) object.Object {
+	evaluated := Eval(stmt.Iterable, env)
+	if isAbrupt(evaluated) {
+		return evaluated
+	}
+
+	iterable, ok := evaluated.(object.Iterable)
+	if !ok {
+		err := newError("%s is not iterable", evaluated.Type())
+		err.Pos, err.End = stmt.Iterable.Pos(), stmt.Iterable.End()
+		return err
+	}
+
+	var result object.Object = NULL
+
+	it := iterable.Iterator()
+	for key, value, ok := it.Next(); ok; key, value, ok = it.Next() {
+		loopEnv := object.NewEnclosedEnvironment(env)
+		if stmt.Key != nil {
+			loopEnv.Set(stmt.Key.Value, key, false)
+		}
+		loopEnv.Set(stmt.Value.Value, value, false)
+
+		result = Eval(stmt.Block, loopEnv)
+		if isError(result) || isReturn(result) {
+			return result
+		}
+		if result == BREAK {
+			return NULL
+		}
+		if result == CONTINUE || result == nil {
+			result = NULL
+		}
+	}
+
+	return result
+}
+
+func evalWhileStatement(stmt *ast.WhileStatement, env *object.Environment) object.Object {
+	var result object.Object = NULL
 
 	for {
 		condition := Eval(stmt.Condition, env)
-		if isError(condition) {
+		if isAbrupt(condition) {
 			return condition
 		}
 		if !isTruthy(condition) {
@@ -290,16 +563,36 @@ func evalForStatement(stmt *ast.ForStatement, env *object.Environment) object.Ob
 		if isError(result) || isReturn(result) {
 			return result
 		}
-
-		postStmt := Eval(stmt.PostStatement, env)
-		if isError(postStmt) {
-			return postStmt
+		if result == BREAK {
+			return NULL
+		}
+		if result == CONTINUE || result == nil {
+			result = NULL
 		}
 	}
 
 	return result
 }
 
+// evalRangeExpression returns the range from..to. Both bounds must fit int64.
+func evalRangeExpression(from object.Object, to object.Object) object.Object {
+	for _, bound := range []object.Object{from, to} {
+		if _, ok := bound.(*object.BigInt); ok {
+			return newError("range bound out of int64 range: %s", bound.Inspect())
+		}
+	}
+
+	start, ok := from.(*object.Integer)
+	if !ok {
+		return newError("range bounds must be INTEGER, got %s..%s", from.Type(), to.Type())
+	}
+	end, ok := to.(*object.Integer)
+	if !ok {
+		return newError("range bounds must be INTEGER, got %s..%s", from.Type(), to.Type())
+	}
+	return &object.Range{Start: start.Value, End: end.Value}
+}
+
 func isTruthy(obj object.Object) bool {
 	switch obj {
 	case NULL:
@@ -329,6 +622,40 @@ func isReturn(obj object.Object) bool {
 	return false
 }
 
+// isAbrupt reports whether obj ends the evaluation of the enclosing
+// expression early instead of being a value: an error, a return value, or a
+// break or continue leaving an if expression.
+func isAbrupt(obj object.Object) bool {
+	if obj == nil {
+		return false
+	}
+	switch obj.Type() {
+	case object.ERROR_OBJ, object.RETURN_VALUE_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
+		return true
+	default:
+		return false
+	}
+}
+
+// evalInterpolatedString concatenates the text of the string with the
+// Inspect() form of the values of its embedded expressions.
+func evalInterpolatedString(node *ast.InterpolatedString, env *object.Environment) object.Object {
+	var out bytes.Buffer
+
+	for _, part := range node.Parts {
+		evaluated := Eval(part, env)
+		if isAbrupt(evaluated) {
+			return evaluated
+		}
+		if evaluated == nil {
+			evaluated = NULL
+		}
+		out.WriteString(evaluated.Inspect())
+	}
+
+	return &object.String{Value: out.String()}
+}
+
 func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
 	if val, ok := env.Get(node.Value); ok {
 		return val.Obj
@@ -336,7 +663,7 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 	if builtin, ok := builtins[node.Value]; ok {
 		return builtin
 	}
-	return newError("identifier not found: " + node.Value)
+	return newError("identifier not found: %s", node.Value)
 }
 
 func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
@@ -344,7 +671,7 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 
 	for _, e := range exps {
 		evaluated := Eval(e, env)
-		if isError(evaluated) {
+		if isAbrupt(evaluated) {
 			return []object.Object{evaluated}
 		}
 		result = append(result, evaluated)
@@ -353,32 +680,174 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 	return result
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
+// evalNamedArguments evaluates the "name: value" arguments of a call in order.
+func evalNamedArguments(args []*ast.NamedArgument, env *object.Environment) (map[string]object.Object, object.Object) {
+	named := make(map[string]object.Object, len(args))
+
+	for _, arg := range args {
+		if _, ok := named[arg.Name.Value]; ok {
+			err := newError("argument %s given more than once", arg.Name.Value)
+			err.Pos, err.End = arg.Pos(), arg.End()
+			return nil, err
+		}
+
+		evaluated := Eval(arg.Value, env)
+		if isAbrupt(evaluated) {
+			return nil, evaluated
+		}
+		named[arg.Name.Value] = evaluated
+	}
+
+	return named, nil
+}
+
+func applyFunction(fn object.Object, args []object.Object, named map[string]object.Object, call *ast.CallExpression) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
-		extentedEnv := extendedFunctionEnv(fn, args)
+		extentedEnv, err := extendedFunctionEnv(fn, args, named, call)
+		if err != nil {
+			return err
+		}
 		evaluated := Eval(fn.Body, extentedEnv)
+		if err, ok := evaluated.(*object.Error); ok {
+			err.Stack = append(err.Stack, newFrame(fn, call))
+		}
 		return unwrapReturnValue(evaluated)
 	case *object.Builtin:
+		if len(named) > 0 {
+			return newError("builtin functions do not take named arguments")
+		}
+		if err := checkBuiltinArity(fn, len(args)); err != nil {
+			return err
+		}
 		return fn.Fn(args...)
 	default:
 		return newError("not a function: %s", fn.Type())
 	}
 }
 
-func extendedFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+func checkBuiltinArity(fn *object.Builtin, got int) *object.Error {
+	switch {
+	case fn.MaxArgs < 0 && got < fn.MinArgs:
+		return newError("wrong number of arguments. got=%d, want>=%d", got, fn.MinArgs)
+	case fn.MaxArgs < 0:
+		return nil
+	case fn.MinArgs == fn.MaxArgs && got != fn.MinArgs:
+		return newError("wrong number of arguments. got=%d, want=%d", got, fn.MinArgs)
+	case got < fn.MinArgs || got > fn.MaxArgs:
+		return newError("wrong number of arguments. got=%d, want=%d..%d", got, fn.MinArgs, fn.MaxArgs)
+	default:
+		return nil
+	}
+}
+
+// pluralize returns e.g. "1 argument" or "2 arguments".
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+func newFrame(fn *object.Function, call *ast.CallExpression) object.Frame {
+	name := fn.Name
+	if name == "" {
+		name = "<anonymous>"
+	}
+	return object.Frame{Function: name, Pos: call.Pos()}
+}
+
+// extendedFunctionEnv binds the arguments of a call to the parameters of fn.
+// Parameters left without an argument take their default, evaluated at call
+// time in the new environment, so a default can refer to the closure and to
+// the parameters before it.
+func extendedFunctionEnv(fn *object.Function, args []object.Object, named map[string]object.Object, call *ast.CallExpression) (*object.Environment, object.Object) {
+	if len(args) > len(fn.Parameters) && fn.Rest == nil {
+		return nil, arityError(fn, len(args))
+	}
+
+	for _, arg := range call.NamedArguments {
+		var err *object.Error
+		if idx := parameterIndex(fn, arg.Name.Value); idx < 0 {
+			err = newError("unexpected named argument: %s", arg.Name.Value)
+		} else if idx < len(args) {
+			err = newError("argument %s given more than once", arg.Name.Value)
+		}
+		if err != nil {
+			err.Pos, err.End = arg.Pos(), arg.End()
+			return nil, err
+		}
+	}
+
 	env := object.NewEnclosedEnvironment(fn.Env)
 
 	for paramIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIdx], true)
+		var arg object.Object
+		if paramIdx < len(args) {
+			arg = args[paramIdx]
+		} else if val, ok := named[param.Value]; ok {
+			arg = val
+		} else if paramIdx < len(fn.Defaults) && fn.Defaults[paramIdx] != nil {
+			arg = Eval(fn.Defaults[paramIdx], env)
+			if err, ok := arg.(*object.Error); ok {
+				err.Stack = append(err.Stack, newFrame(fn, call))
+				return nil, err
+			}
+		} else if len(named) == 0 {
+			return nil, arityError(fn, len(args))
+		} else {
+			return nil, newError("missing argument: %s", param.Value)
+		}
+		env.Set(param.Value, arg, true)
 	}
 
-	return env
+	if fn.Rest != nil {
+		rest := []object.Object{}
+		if len(args) > len(fn.Parameters) {
+			rest = append(rest, args[len(fn.Parameters):]...)
+		}
+		env.Set(fn.Rest.Value, &object.Array{Elements: rest}, true)
+	}
+
+	return env, nil
+}
+
+func parameterIndex(fn *object.Function, name string) int {
+	for i, param := range fn.Parameters {
+		if param.Value == name {
+			return i
+		}
+	}
+	return -1
 }
 
+// arityError reports a call of fn with the wrong number of positional arguments.
+func arityError(fn *object.Function, got int) *object.Error {
+	required := 0
+	for i := range fn.Parameters {
+		if i >= len(fn.Defaults) || fn.Defaults[i] == nil {
+			required++
+		}
+	}
+
+	switch {
+	case required == len(fn.Parameters) && fn.Rest == nil:
+		return newError("function expects %s, got %d", pluralize(required, "argument"), got)
+	case got < required:
+		return newError("function expects at least %s, got %d", pluralize(required, "argument"), got)
+	default:
+		return newError("function expects at most %s, got %d", pluralize(len(fn.Parameters), "argument"), got)
+	}
+}
+
+// unwrapReturnValue returns the result of a function body. A body that
+// produces no value, such as an empty one, returns NULL.
 func unwrapReturnValue(obj object.Object) object.Object {
 	if returnValue, ok := obj.(*object.ReturnValue); ok {
-		return returnValue.Value
+		obj = returnValue.Value
+	}
+	if obj == nil {
+		return NULL
 	}
 	return obj
 }
@@ -387,6 +856,8 @@ func evalIndexExpression(left object.Object, index object.Object) object.Object
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
 	default:
@@ -396,16 +867,183 @@ func evalIndexExpression(left object.Object, index object.Object) object.Object
 
 func evalArrayIndexExpression(array object.Object, index object.Object) object.Object {
 	arrayObj := array.(*object.Array)
-	idx := index.(*object.Integer).Value
-	max := int64(len(arrayObj.Elements) - 1)
 
-	if idx < 0 || idx > max {
+	idx, ok := normalizeIndex(index, len(arrayObj.Elements))
+	if !ok {
 		return NULL
 	}
 
 	return arrayObj.Elements[idx]
 }
 
+func evalStringIndexExpression(str object.Object, index object.Object) object.Object {
+	chars := []rune(str.(*object.String).Value)
+
+	idx, ok := normalizeIndex(index, len(chars))
+	if !ok {
+		return NULL
+	}
+
+	return &object.String{Value: string(chars[idx])}
+}
+
+// normalizeIndex converts an integer index into a position in a sequence of
+// the given length, counting from the end when it is negative. It reports
+// false when the index is out of range, which a BigInt index always is.
+func normalizeIndex(index object.Object, length int) (int, bool) {
+	integer, ok := index.(*object.Integer)
+	if !ok {
+		return 0, false
+	}
+	idx := integer.Value
+
+	if idx < 0 {
+		idx += int64(length)
+	}
+	if idx < 0 || idx >= int64(length) {
+		return 0, false
+	}
+
+	return int(idx), true
+}
+
+// evalSliceExpression evaluates left[low:high:step] on an array or a string,
+// following Python: negative bounds count from the end, bounds outside the
+// sequence are clamped, and a negative step walks backwards.
+func evalSliceExpression(node *ast.SliceExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isAbrupt(left) {
+		return left
+	}
+
+	var length int
+	var chars []rune
+	switch left := left.(type) {
+	case *object.Array:
+		length = len(left.Elements)
+	case *object.String:
+		chars = []rune(left.Value)
+		length = len(chars)
+	default:
+		return newError("slice operator not supported: %s", left.Type())
+	}
+
+	low, err := evalSliceBound(node.Low, env)
+	if err != nil {
+		return err
+	}
+	high, err := evalSliceBound(node.High, env)
+	if err != nil {
+		return err
+	}
+	step, err := evalSliceBound(node.Step, env)
+	if err != nil {
+		return err
+	}
+	if step == nil {
+		one := int64(1)
+		step = &one
+	}
+	if *step == 0 {
+		return newError("slice step cannot be zero")
+	}
+
+	indices := sliceIndices(length, low, high, *step)
+
+	switch left := left.(type) {
+	case *object.Array:
+		elements := make([]object.Object, len(indices))
+		for i, idx := range indices {
+			elements[i] = left.Elements[idx]
+		}
+		return &object.Array{Elements: elements}
+	default:
+		out := make([]rune, len(indices))
+		for i, idx := range indices {
+			out[i] = chars[idx]
+		}
+		return &object.String{Value: string(out)}
+	}
+}
+
+// evalSliceBound evaluates an optional slice bound, returning nil when it is
+// omitted. A BigInt bound is clamped to the range of int64.
+func evalSliceBound(exp ast.Expression, env *object.Environment) (*int64, object.Object) {
+	if exp == nil {
+		return nil, nil
+	}
+
+	evaluated := Eval(exp, env)
+	if isAbrupt(evaluated) {
+		return nil, evaluated
+	}
+
+	var value int64
+	switch evaluated := evaluated.(type) {
+	case *object.Integer:
+		value = evaluated.Value
+	case *object.BigInt:
+		value = math.MaxInt64
+		if evaluated.Value.Sign() < 0 {
+			value = math.MinInt64
+		}
+	default:
+		err := newError("slice indices must be INTEGER, got %s", evaluated.Type())
+		err.Pos, err.End = exp.Pos(), exp.End()
+		return nil, err
+	}
+
+	return &value, nil
+}
+
+// sliceIndices returns the positions that low:high:step selects from a
+// sequence of the given length. Omitted bounds are nil; step is not zero.
+func sliceIndices(length int, low *int64, high *int64, step int64) []int {
+	n := int64(length)
+
+	// with a negative step, -1 stands for "before the first element"
+	lower, upper := int64(0), n
+	if step < 0 {
+		lower, upper = -1, n-1
+	}
+	adjust := func(bound *int64, omitted int64) int64 {
+		if bound == nil {
+			return omitted
+		}
+		x := *bound
+		if x < 0 {
+			x += n
+		}
+		if x < lower {
+			return lower
+		}
+		if x > upper {
+			return upper
+		}
+		return x
+	}
+
+	var start, stop int64
+	if step > 0 {
+		start, stop = adjust(low, lower), adjust(high, upper)
+	} else {
+		start, stop = adjust(low, upper), adjust(high, lower)
+	}
+
+	var count int64
+	if step > 0 && start < stop {
+		count = (stop-start-1)/step + 1
+	} else if step < 0 && start > stop {
+		count = (stop-start+1)/step + 1
+	}
+
+	indices := make([]int, count)
+	for i := range indices {
+		indices[i] = int(start + int64(i)*step)
+	}
+	return indices
+}
+
 func evalHashIndexExpression(hash object.Object, index object.Object) object.Object {
 	hashObject := hash.(*object.Hash)
 
@@ -428,7 +1066,7 @@ func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Obje
 
 	for keyNode, valueNode := range node.Pairs {
 		key := Eval(keyNode, env)
-		if isError(key) {
+		if isAbrupt(key) {
 			return key
 		}
 
@@ -438,7 +1076,7 @@ func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Obje
 		}
 
 		value := Eval(valueNode, env)
-		if isError(value) {
+		if isAbrupt(value) {
 			return value
 		}
 
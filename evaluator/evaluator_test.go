@@ -1,9 +1,12 @@
 package evaluator
 
 import (
+	"io"
+	"math"
 	"monkey/lexer"
 	"monkey/object"
 	"monkey/parser"
+	"os"
 	"testing"
 )
 
@@ -23,6 +26,22 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"5 + 5 * 5", 30},
 		{"5 / 5 * 5", 5},
 		{"5 - 5 * 5", -20},
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"2 ** 10", 1024},
+		{"2 ** 3 ** 2", 512},
+		{"-2 ** 2", -4},
+		{"(-2) ** 3", -8},
+		{"7 ** 0", 1},
+		{"12 & 10", 8},
+		{"12 | 10", 14},
+		{"12 ^ 10", 6},
+		{"~0", -1},
+		{"~5 & 7", 2},
+		{"1 << 4", 16},
+		{"256 >> 4", 16},
+		{"-16 >> 2", -4},
+		{"1 << 2 + 1", 8},
 	}
 
 	for _, tt := range tests {
@@ -53,6 +72,104 @@ func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
 	return true
 }
 
+func TestNumericLiteralForms(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"0xFF", "255"},
+		{"0XfF", "255"},
+		{"0o755", "493"},
+		{"0O17", "15"},
+		{"0755", "493"},
+		{"0b1010", "10"},
+		{"0B1", "1"},
+		{"1_000_000", "1000000"},
+		{"0xFF_FF", "65535"},
+		{"0b_1111_0000 | 0b1", "241"},
+		{"0x1_0000_0000_0000_0000", "18446744073709551616"},
+		{"-0x8000_0000_0000_0000", "-9223372036854775808"},
+		{"1_000.5", "1000.5"},
+		{"1_0e1_0", "1e+11"},
+		{"0o777 & 0o022", "18"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: got %s, want %s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"3.5", 3.5},
+		{"-2.5", -2.5},
+		{"1.5 + 1.5", 3.0},
+		{"1 + 0.5", 1.5},
+		{"0.5 * 4", 2.0},
+		{"7 / 2.0", 3.5},
+		{"10 - .25", 9.75},
+		{"1 < 1.5", true},
+		{"2.0 > 3", false},
+		{"2 == 2.0", true},
+		{"2.5 != 2.5", false},
+		{"(1 + 2 + 4) / 2.0", 3.5},
+		{"7.5 % 2", 1.5},
+		{"2.0 ** 0.5", math.Sqrt2},
+		{"2 ** -1", 0.5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		t.Run(tt.input, func(t *testing.T) {
+			switch expected := tt.expected.(type) {
+			case float64:
+				testFloatObject(t, evaluated, expected)
+			case bool:
+				testBooleanObject(t, evaluated, expected)
+			}
+		})
+	}
+}
+
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("object is %T (%+v), want Float", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("Value is %g, want %g", result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func TestFloatInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2.0", "2.0"},
+		{"0.1 + 0.2", "0.30000000000000004"},
+		{"1e21", "1e+21"},
+		{"-0.5", "-0.5"},
+		{"1 / 0.0", "+Inf"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: wrong inspect. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
 func TestEvalBooleanExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -60,11 +177,30 @@ func TestEvalBooleanExpression(t *testing.T) {
 	}{
 		{"true", true},
 		{"false", false},
+		{"1 <= 2", true},
+		{"2 <= 2", true},
+		{"3 <= 2", false},
+		{"1 >= 2", false},
+		{"2 >= 2", true},
+		{"2.5 >= 2", true},
+		{"1.5 <= 1", false},
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 < 2 && 2 < 3", true},
+		{"1 > 2 || 2 > 3", false},
+		{"1 && \"a\"", true},
+		{"false || 0", true},
+		// the right operand is not evaluated when the left one decides
+		{"false && undefined", false},
+		{"true || undefined", true},
+		{"var n = 0; true || (n = 1); n == 0", true},
 	}
 
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
-		testBooleanObject(t, evaluated, tt.expected)
+		t.Run(tt.input, func(t *testing.T) { testBooleanObject(t, evaluated, tt.expected) })
 	}
 }
 
@@ -186,6 +322,26 @@ func TestErrorHandling(t *testing.T) {
 			"-true",
 			"unknown operator: -BOOLEAN",
 		},
+		{
+			"~1.5",
+			"unknown operator: ~FLOAT",
+		},
+		{
+			"1.5 & 1",
+			"unknown operator: FLOAT & INTEGER",
+		},
+		{
+			"1 << -1",
+			"negative shift count: -1",
+		},
+		{
+			"1 / 0",
+			"division by zero",
+		},
+		{
+			"let zero = 0; 5 % zero",
+			"modulo by zero",
+		},
 		{
 			"true + false",
 			"unknown operator: BOOLEAN + BOOLEAN",
@@ -221,6 +377,50 @@ if (10 > 1) {
 			"unusable as hash key: FUNCTION",
 
 		},
+		{
+			"let a = [1]; a[0] = 2;",
+			"can't assign value to immutable identifier: a",
+		},
+		{
+			"let x = 1; x += 1;",
+			"can't assign value to immutable identifier: x",
+		},
+		{
+			"var a = [1]; a[1] = 2;",
+			"index out of range: 1",
+		},
+		{
+			"var a = [1]; a[5] += 2;",
+			"index out of range: 5",
+		},
+		{
+			`var h = {}; h["k"] += 1;`,
+			"key not found: k",
+		},
+		{
+			`var h = {}; h[[1]] = 1;`,
+			"unusable as hash key: ARRAY",
+		},
+		{
+			"var n = 1; n[0] = 1;",
+			"index assignment not supported: INTEGER",
+		},
+		{
+			"let f = fn() {}; var x = 1; x += f();",
+			"type mismatch: INTEGER + NULL",
+		},
+		{
+			"let f = fn() {}; var a = [1]; a[0] *= f();",
+			"type mismatch: INTEGER * NULL",
+		},
+		{
+			`"a ${1 + true} b"`,
+			"type mismatch: INTEGER + BOOLEAN",
+		},
+		{
+			`var x = 1; x += "a";`,
+			"type mismatch: INTEGER + STRING",
+		},
 	}
 
 	for _, tt := range tests {
@@ -239,6 +439,130 @@ if (10 > 1) {
 
 }
 
+func TestBigIntegers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"9223372036854775807 * 2", "18446744073709551614"},
+		{"-9223372036854775808", int64(math.MinInt64)},
+		{"-9223372036854775808 / -1", "9223372036854775808"},
+		{"-(-9223372036854775808)", "9223372036854775808"},
+		{"2 ** 64", "18446744073709551616"},
+		{"1 << 64", "18446744073709551616"},
+		{"(1 << 64) >> 64", int64(1)},
+		{"3 ** 50 % 1000", int64(249)},
+		{"99999999999999999999", "99999999999999999999"},
+		{"99999999999999999999 - 99999999999999999998", int64(1)},
+		{"99999999999999999999 / 10", "9999999999999999999"},
+		{"99999999999999999999 / 100", int64(999999999999999999)},
+		{"-(2 ** 70) / 3", "-393530540239137101141"},
+		{"-(2 ** 70) % 3", int64(-1)},
+		{"~(2 ** 64)", "-18446744073709551617"},
+		{"(2 ** 64 + 5) & 7", int64(5)},
+		{"2 ** 64 > 1", true},
+		{"-(2 ** 64) < 1", true},
+		{"2 ** 64 == 2 ** 64", true},
+		{"2 ** 64 != 2 ** 64 + 1", true},
+		{"2 ** 64 == 2.0 ** 64", true},
+		{"2 ** 64 * 0.5", 9223372036854775808.0},
+		{"2 ** 64 / 0", "division by zero"},
+		{"3 ** 100000000000000", "exponent too large: 100000000000000"},
+		{"(2 ** 64) ** 2000000", "exponent too large: 2000000"},
+		{"2 ** (2 ** 64)", "exponent too large: 18446744073709551616"},
+		{"1 ** (2 ** 64)", int64(1)},
+		{"(-1) ** (2 ** 64 + 1)", int64(-1)},
+		{"0 ** 100000000000000", int64(0)},
+		{"(2 ** 1000000) >> 999999", int64(2)},
+		{"int(1e19)", "10000000000000000000"},
+		{`int("-123456789012345678901234567890")`, "-123456789012345678901234567890"},
+		{"float(2 ** 64)", 18446744073709551616.0},
+		{"let h = {2 ** 64: 1, 2: 2}; h[2 ** 65 / 2]", int64(1)},
+		{"[1, 2][2 ** 64]", nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		t.Run(tt.input, func(t *testing.T) {
+			switch expected := tt.expected.(type) {
+			case int64:
+				testIntegerObject(t, evaluated, expected)
+			case float64:
+				testFloatObject(t, evaluated, expected)
+			case bool:
+				testBooleanObject(t, evaluated, expected)
+			case nil:
+				testNullObject(t, evaluated)
+			case string:
+				switch obj := evaluated.(type) {
+				case *object.BigInt:
+					if obj.Inspect() != expected {
+						t.Errorf("Value is %s, want %s", obj.Inspect(), expected)
+					}
+				case *object.Error:
+					if obj.Message != expected {
+						t.Errorf("wrong error message. expected=%q, got=%q", expected, obj.Message)
+					}
+				default:
+					t.Errorf("object is %T (%+v), want BigInt", evaluated, evaluated)
+				}
+			}
+		})
+	}
+}
+
+func TestIntegerOverflow(t *testing.T) {
+	const (
+		maxInt = "9223372036854775807"
+		minInt = "(-9223372036854775807 - 1)"
+	)
+
+	tests := []struct {
+		input    string
+		reported interface{}
+		wrapped  int64
+	}{
+		{maxInt + " + 1", "integer overflow", math.MinInt64},
+		{minInt + " - 1", "integer overflow", math.MaxInt64},
+		{maxInt + " * 2", "integer overflow", -2},
+		{minInt + " * -1", "integer overflow", math.MinInt64},
+		{"-1 * " + minInt, "integer overflow", math.MinInt64},
+		{minInt + " / -1", "integer overflow", math.MinInt64},
+		{"-" + minInt, "integer overflow", math.MinInt64},
+		{"2 ** 63", "integer overflow", math.MinInt64},
+		{"2 ** 62", int64(1 << 62), 1 << 62},
+		{"(-2) ** 63", int64(math.MinInt64), math.MinInt64},
+		{maxInt + " - 1 + 1", int64(math.MaxInt64), math.MaxInt64},
+		{minInt + " + " + maxInt, int64(-1), -1},
+		{"3037000499 * 3037000499", int64(9223372030926249001), 9223372030926249001},
+		{"1 << 63", int64(math.MinInt64), math.MinInt64},
+		{"1 << 64", int64(0), 0},
+	}
+
+	defer func() { IntegerOverflow = PromoteToBigInt }()
+
+	for _, tt := range tests {
+		IntegerOverflow = ReportOverflow
+		evaluated := testEval(tt.input)
+		switch expected := tt.reported.(type) {
+		case int64:
+			testIntegerObject(t, evaluated, expected)
+		case string:
+			err, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("%s: object is not Error. got=%T (%+v)", tt.input, evaluated, evaluated)
+			} else if err.Message != expected {
+				t.Errorf("%s: wrong error message. expected=%q, got=%q", tt.input, expected, err.Message)
+			}
+		}
+
+		IntegerOverflow = WrapAround
+		testIntegerObject(t, testEval(tt.input), tt.wrapped)
+	}
+}
+
 func TestLetStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -297,6 +621,162 @@ func TestFunctionApplication(t *testing.T) {
 	}
 }
 
+func TestFunctionArity(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(a, b) { a }(1)", "function expects 2 arguments, got 1"},
+		{"fn(a) { a }(1, 2)", "function expects 1 argument, got 2"},
+		{"fn() { 1 }(1)", "function expects 0 arguments, got 1"},
+		{"let add = fn(a, b) { a + b }; add(1, 2, 3)", "function expects 2 arguments, got 3"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%s: no error object returned. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("%s: wrong error message. expected=%q, got=%q", tt.input, tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestDefaultAndRestParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let f = fn(a, b = 10) { a + b }; f(1)", 11},
+		{"let f = fn(a, b = 10) { a + b }; f(1, 2)", 3},
+		{"let f = fn(a, b = a * 2) { a + b }; f(3)", 9},
+		{"let k = 5; let f = fn(a = k) { a }; f()", 5},
+		{"var n = 0; let f = fn(a = n) { a }; n = 7; f()", 7},
+		{"let f = fn(xs = []) { push(xs, 1) }; f(); len(f())", 1},
+		{"let f = fn(a, ...rest) { rest }; f(1, 2, 3)", "[2, 3]"},
+		{"let f = fn(a, ...rest) { rest }; f(1)", "[]"},
+		{"let f = fn(...all) { len(all) }; f()", 0},
+		{"let f = fn(a, b = 1, ...rest) { [a, b, rest] }; f(5, 6, 7)", "[5, 6, [7]]"},
+		{"let f = fn(a, b = 1) { a }; f()", "function expects at least 1 argument, got 0"},
+		{"let f = fn(a, b = 1) { a }; f(1, 2, 3)", "function expects at most 2 arguments, got 3"},
+		{"let f = fn(a, b, ...rest) { a }; f(1)", "function expects at least 2 arguments, got 1"},
+		{"let f = fn(a = undefined) { a }; f()", "identifier not found: undefined"},
+	}
+
+	for _, tt := range tests {
+		testCallResult(t, tt.input, tt.expected)
+	}
+}
+
+func TestNamedArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let f = fn(a, b) { a - b }; f(b: 2, a: 10)", 8},
+		{"let f = fn(a, b) { a - b }; f(10, b: 2)", 8},
+		{"let f = fn(a, b = 1, c = 2) { [a, b, c] }; f(0, c: 5)", "[0, 1, 5]"},
+		{"let f = fn(a, b = a + 1) { b }; f(a: 4)", 5},
+		{"let f = fn(a, b) { a }; f(1, c: 2)", "unexpected named argument: c"},
+		{"let f = fn(a, b) { a }; f(1, a: 2)", "argument a given more than once"},
+		{"let f = fn(a, b) { a }; f(a: 1, a: 2)", "argument a given more than once"},
+		{"let f = fn(a, b) { a }; f(b: 1)", "missing argument: a"},
+		{"let f = fn(a, ...rest) { a }; f(1, rest: 2)", "unexpected named argument: rest"},
+		{`len(x: "a")`, "builtin functions do not take named arguments"},
+	}
+
+	for _, tt := range tests {
+		testCallResult(t, tt.input, tt.expected)
+	}
+}
+
+// testCallResult checks an integer result, or the Inspect() of any other
+// result, or an error message.
+func testCallResult(t *testing.T, input string, expected interface{}) {
+	evaluated := testEval(input)
+
+	switch expected := expected.(type) {
+	case int:
+		if !testIntegerObject(t, evaluated, int64(expected)) {
+			t.Errorf("input: %s", input)
+		}
+	case string:
+		got := evaluated.Inspect()
+		if err, ok := evaluated.(*object.Error); ok {
+			got = err.Message
+		}
+		if got != expected {
+			t.Errorf("%s: expected %q, got %q", input, expected, got)
+		}
+	}
+}
+
+func TestBuiltinArity(t *testing.T) {
+	tests := []struct {
+		builtin  *object.Builtin
+		args     int
+		expected string
+	}{
+		{&object.Builtin{MinArgs: 1, MaxArgs: 1}, 1, ""},
+		{&object.Builtin{MinArgs: 1, MaxArgs: 1}, 2, "wrong number of arguments. got=2, want=1"},
+		{&object.Builtin{MinArgs: 1, MaxArgs: 3}, 3, ""},
+		{&object.Builtin{MinArgs: 1, MaxArgs: 3}, 0, "wrong number of arguments. got=0, want=1..3"},
+		{&object.Builtin{MinArgs: 1, MaxArgs: 3}, 4, "wrong number of arguments. got=4, want=1..3"},
+		{&object.Builtin{MinArgs: 2, MaxArgs: -1}, 5, ""},
+		{&object.Builtin{MinArgs: 2, MaxArgs: -1}, 1, "wrong number of arguments. got=1, want>=2"},
+	}
+
+	for _, tt := range tests {
+		err := checkBuiltinArity(tt.builtin, tt.args)
+		if tt.expected == "" && err != nil {
+			t.Errorf("%d..%d with %d args: unexpected error %q", tt.builtin.MinArgs, tt.builtin.MaxArgs, tt.args, err.Message)
+		}
+		if tt.expected != "" && (err == nil || err.Message != tt.expected) {
+			t.Errorf("%d..%d with %d args: expected error %q, got=%v", tt.builtin.MinArgs, tt.builtin.MaxArgs, tt.args, tt.expected, err)
+		}
+	}
+}
+
+func TestFunctionWithoutValue(t *testing.T) {
+	tests := []string{
+		"let f = fn() {}; f()",
+		"let f = fn() { let x = 1 }; f()",
+		"let f = fn() { if (false) { 1 } }; f()",
+		"let f = fn() {}; let x = f(); x",
+	}
+
+	for _, input := range tests {
+		testNullObject(t, testEval(input))
+	}
+}
+
+func TestPutsFunctionWithoutValue(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	evaluated := testEval("let f = fn() {}; puts(f())")
+	w.Close()
+	os.Stdout = stdout
+
+	output, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testNullObject(t, evaluated)
+	if string(output) != "null\n" {
+		t.Errorf("puts printed %q, want %q", output, "null\n")
+	}
+}
+
 func TestClosure(t *testing.T) {
 	input := `
 let newAdder = fn(x) {
@@ -324,6 +804,35 @@ func TestStringLiteral(t *testing.T) {
 	}
 }
 
+func TestInterpolatedStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let name = "monkey"; "hello ${name}!"`, "hello monkey!"},
+		{`let items = [1, 2]; "${len(items)} items: ${items}"`, "2 items: [1, 2]"},
+		{`"${1 + 2}${true}${1.5}"`, "3true1.5"},
+		{`let h = {"k": "v"}; "k is ${h["k"]}"`, "k is v"},
+		{`let n = 2; "outer ${"inner ${n * 2}"}"`, "outer inner 4"},
+		{`"${if (false) { 1 }}"`, "null"},
+		{`"${fn(x) { x }(7)}"`, "7"},
+		{`let f = fn() {}; "${f()}"`, "null"},
+		{`let f = fn() { let x = 1 }; "a${f()}b"`, "anullb"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("%s: object is not String. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("%s: got %q, want %q", tt.input, str.Value, tt.expected)
+		}
+	}
+}
+
 func TestStringConcatenation(t *testing.T) {
 	input := `"Hello" + " " + "World!"`
 
@@ -346,8 +855,31 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`len("")`, 0},
 		{`len("four")`, 4},
 		{`len("hello world")`, 11},
+		{`len("a\tb\n")`, 4},
+		{"len(`a\\tb\n`)", 5},
+		{`len("日本語")`, 3},
+		{`len("café")`, 4},
 		{`len(1)`, "argument to `len` not supported, got INTEGER"},
 		{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
+		{`len()`, "wrong number of arguments. got=0, want=1"},
+		{`push([])`, "wrong number of arguments. got=1, want=2"},
+		{`int(3.9)`, 3},
+		{`int(-3.9)`, -3},
+		{`int("42")`, 42},
+		{`int("4.2")`, `could not parse "4.2" as integer`},
+		{`int(0.0 / 0)`, "cannot convert NaN to INTEGER"},
+		{`float(3)`, 3.0},
+		{`float("2.5")`, 2.5},
+		{`float("x")`, `could not parse "x" as float`},
+		{`floor(2.7)`, 2.0},
+		{`floor(-2.5)`, -3.0},
+		{`ceil(2.1)`, 3.0},
+		{`round(2.5)`, 3.0},
+		{`round(-2.5)`, -3.0},
+		{`round(7)`, 7},
+		{`round("7")`, "argument to `round` must be INTEGER or FLOAT, got STRING"},
+		{`bytes(1)`, "argument to `bytes` must be STRING, got INTEGER"},
+		{`runes([])`, "argument to `runes` must be STRING, got ARRAY"},
 	}
 
 	for _, tt := range tests {
@@ -356,6 +888,8 @@ func TestBuiltinFunctions(t *testing.T) {
 		switch expected := tt.expected.(type) {
 		case int:
 			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
 		case string:
 			err, ok := evaluated.(*object.Error)
 			if !ok {
@@ -412,12 +946,24 @@ func TestArrayIndexExpressions(t *testing.T) {
 		},
 		{
 			"[1, 2, 3][-1]",
+			3,
+		},
+		{
+			"[1, 2, 3][-3]",
+			1,
+		},
+		{
+			"[1, 2, 3][-4]",
 			nil,
 		},
 		{
 			"[1, 2, 3][1+1]",
 			3,
 		},
+		{
+			"var a = [1, 2, 3]; a[-1] = 30; a[2];",
+			30,
+		},
 	}
 
 	for _, tt := range tests {
@@ -432,6 +978,105 @@ func TestArrayIndexExpressions(t *testing.T) {
 
 }
 
+func TestBytesAndRunes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`bytes("")`, "[]"},
+		{`bytes("ab")`, "[97, 98]"},
+		{`bytes("日")`, "[230, 151, 165]"},
+		{`bytes("\xff")`, "[255]"},
+		{`runes("ab")`, "[97, 98]"},
+		{`runes("日本")`, "[26085, 26412]"},
+		{`runes("\u{1F600}")`, "[128512]"},
+		{`len(bytes("日本")) == 6 && len(runes("日本")) == 2`, "true"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: got %s, want %s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestStringIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"monkey"[0]`, "m"},
+		{`"monkey"[5]`, "y"},
+		{`"monkey"[-2]`, "e"},
+		{`"monkey"[6]`, nil},
+		{`"monkey"[-7]`, nil},
+		{`""[0]`, nil},
+		{`"日本語"[1]`, "本"},
+		{`"日本語"[-1]`, "語"},
+		{`"日本語"[3]`, nil},
+		{`let ラベル = "名前"; ラベル[0]`, "名"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		expected, ok := tt.expected.(string)
+		if !ok {
+			testNullObject(t, evaluated)
+			continue
+		}
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("%s: object is not String. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if str.Value != expected {
+			t.Errorf("%s: got %q, want %q", tt.input, str.Value, expected)
+		}
+	}
+}
+
+func TestSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2, 3, 4, 5][1:3]", "[2, 3]"},
+		{"[1, 2, 3, 4, 5][:2]", "[1, 2]"},
+		{"[1, 2, 3, 4, 5][3:]", "[4, 5]"},
+		{"[1, 2, 3, 4, 5][:]", "[1, 2, 3, 4, 5]"},
+		{"[1, 2, 3, 4, 5][::2]", "[1, 3, 5]"},
+		{"[1, 2, 3, 4, 5][1::2]", "[2, 4]"},
+		{"[1, 2, 3, 4, 5][-2:]", "[4, 5]"},
+		{"[1, 2, 3, 4, 5][:-2]", "[1, 2, 3]"},
+		{"[1, 2, 3, 4, 5][::-1]", "[5, 4, 3, 2, 1]"},
+		{"[1, 2, 3, 4, 5][3:0:-1]", "[4, 3, 2]"},
+		{"[1, 2, 3, 4, 5][-1:-4:-2]", "[5, 3]"},
+		{"[1, 2, 3, 4, 5][-100:100]", "[1, 2, 3, 4, 5]"},
+		{"[1, 2, 3, 4, 5][4:1]", "[]"},
+		{"[1, 2, 3, 4, 5][1:4:100]", "[2]"},
+		{"[1, 2, 3, 4, 5][0:100000000000000000000]", "[1, 2, 3, 4, 5]"},
+		{"[][1:]", "[]"},
+		{`"monkey"[1:4]`, "onk"},
+		{`"monkey"[::-1]`, "yeknom"},
+		{`"monkey"[-3:]`, "key"},
+		{`"こんにちは"[1:3]`, "んに"},
+		{`"日本語"[::-1]`, "語本日"},
+		{`"café"[-1:]`, "é"},
+		{"let a = [1, 2, 3]; let b = a[:]; a == b;", "false"},
+		{"[1, 2, 3][::0]", "ERROR: slice step cannot be zero"},
+		{`[1, 2, 3]["a":]`, "ERROR: slice indices must be INTEGER, got STRING"},
+		{`{"a": 1}[0:1]`, "ERROR: slice operator not supported: HASH"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: got %s, want %s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
 func TestHashLiterals(t *testing.T) {
 	input := `let two = "two";
 {
@@ -506,6 +1151,26 @@ func TestHashIndexExpressions(t *testing.T) {
 			`{false:5}[false]`,
 			5,
 		},
+		{
+			`{1.0: 5}[1]`,
+			5,
+		},
+		{
+			`{1: 5}[1.0]`,
+			5,
+		},
+		{
+			`{2 ** 64: 5}[2.0 ** 64]`,
+			5,
+		},
+		{
+			`{1.5: 5}[1]`,
+			nil,
+		},
+		{
+			`var h = {1: 4}; h[1.0] = 5; h[1]`,
+			5,
+		},
 	}
 
 	for _, tt := range tests {
@@ -545,6 +1210,54 @@ func TestAssignExpressions(t *testing.T) {
 			`var x = 1; var y = 2; var z = x = y = 3; z;`,
 			3,
 		},
+		{
+			`var c = 0; let inc = fn() { c = c + 1 }; inc(); inc(); c;`,
+			2,
+		},
+		{
+			`let counter = fn() { var n = 0; fn() { n = n + 1 } }; let next = counter(); next(); next(); next();`,
+			3,
+		},
+		{
+			`var sum = 0; for (var i = 1; i <= 4; i = i + 1) { sum = sum + i; } sum;`,
+			10,
+		},
+		{
+			`var x = 1; let f = fn() { var x = 10; x = x + 1; x }; f() + x;`,
+			12,
+		},
+		{
+			`var x = 1; let f = fn(x) { x = 5 }; f(2); x;`,
+			1,
+		},
+		{
+			`var x = 5; x += 3; x -= 1; x *= 4; x /= 2; x %= 5; x;`,
+			4,
+		},
+		{
+			`var s = "a"; s += "b";`,
+			"ab",
+		},
+		{
+			`var a = [1, 2, 3]; a[1] = 20; a[0] + a[1] + a[2];`,
+			24,
+		},
+		{
+			`var a = [1, [2, 3]]; a[1][0] += 40; a[1][0];`,
+			42,
+		},
+		{
+			`var h = {}; h["a"] = 1; h["b"] = 2; h["a"] += 10; h["a"] + h["b"];`,
+			13,
+		},
+		{
+			`var h = {"xs": [1]}; h["xs"][0] *= 5; h["xs"][0];`,
+			5,
+		},
+		{
+			`var counts = {}; for (w in ["a", "b", "a"]) { counts[w] = if (counts[w]) { counts[w] + 1 } else { 1 } } counts["a"];`,
+			2,
+		},
 	}
 
 	for _, tt := range tests {
@@ -572,9 +1285,222 @@ func TestForStatements(t *testing.T) {
 		{"var a = 5; for (var i = 0; i < 2; i = i + 1) { a = a + 5 } a;", 15},
 		{"var a = 0; for (var i = 0; i < 10; i = i + 1) { a = a + 5 } a;", 50},
 		{"var a = 3; for (; false;) { a = a + 5 } a;", 3},
+		{"var a = 0; for (var i = 0; i < 10; i = i + 1) { if (i == 3) { break; } a = a + 1 } a;", 3},
+		{"var a = 0; for (var i = 0; i < 10; i = i + 1) { if (i % 2 == 0) { continue; } a = a + i } a;", 25},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestForInStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"var sum = 0; for (x in [1, 2, 3]) { sum = sum + x } sum;", 6},
+		{"var sum = 0; for (i, x in [10, 20, 30]) { sum = sum + i * x } sum;", 80},
+		{"var sum = 0; for (i in 0..5) { sum = sum + i } sum;", 10},
+		{"var n = 0; for (i in 5..0) { n = n + 1 } n;", 0},
+		{`var s = ""; for (k, v in {"b": 2, "a": 1, "c": 3}) { s = s + k } s;`, "abc"},
+		{`var sum = 0; for (k, v in {3: 30, 1: 10, 2: 20}) { sum = sum * 10 + k } sum;`, 123},
+		{`var s = ""; for (ch in "monkey") { s = ch + s } s;`, "yeknom"},
+		{`var s = ""; for (i, ch in "abc") { if (i == 1) { continue } s = s + ch } s;`, "ac"},
+		{"var last = 0; for (x in 0..100) { if (x == 7) { break } last = x } last;", 6},
+		{"let find = fn(xs, y) { for (i, x in xs) { if (x == y) { return i } } -1 }; find([4, 5, 6], 6);", 2},
+		{"var ys = []; for (x in [1, 2]) { ys = push(ys, fn() { x }) } ys[0]() + ys[1]();", 3},
+		{"let r = 1..3; var sum = 0; for (x in r) { for (y in r) { sum = sum + x * y } } sum;", 9},
+		{"for (x in [1]) { x = 2 }", "can't assign value to immutable identifier: x"},
+		{"for (x in 10) { x }", "INTEGER is not iterable"},
+		{`for (x in 1.."a") { x }`, "range bounds must be INTEGER, got INTEGER..STRING"},
+		{"(2 ** 64)..(2 ** 64 + 3)", "range bound out of int64 range: 18446744073709551616"},
+		{"0..-(2 ** 63) - 1", "range bound out of int64 range: -9223372036854775809"},
+		{"(2 ** 64).." + `"a"`, "range bound out of int64 range: 18446744073709551616"},
+		{"for (x in []) {}", nil},
+		{"for (x in [1, 2]) {}", nil},
+		{"for (x in [1, 2]) { let y = x }", nil},
+		{"let f = fn() { for (x in []) {} }; [f()][0]", nil},
+		{`let f = fn() { for (x in []) {} }; "${f()}"`, "null"},
+		{"let g = fn() {}; for (x in g()) { x }", "NULL is not iterable"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case nil:
+			testNullObject(t, evaluated)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("%q: got %q, want %q", tt.input, obj.Value, expected)
+				}
+			case *object.Error:
+				if obj.Message != expected {
+					t.Errorf("%q: wrong error message. expected=%q, got=%q", tt.input, expected, obj.Message)
+				}
+			default:
+				t.Errorf("%q: object is not String or Error. got=%T (%+v)", tt.input, evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestRangeInspect(t *testing.T) {
+	evaluated := testEval("0..10")
+	if evaluated.Inspect() != "0..10" {
+		t.Errorf("Inspect() is %q, want %q", evaluated.Inspect(), "0..10")
+	}
+}
+
+func TestWhileStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"var a = 0; while (a < 10) { a = a + 3 } a;", 12},
+		{"var a = 3; while (false) { a = a + 5 } a;", 3},
+		{"var a = 0; while (true) { a = a + 1; if (a == 5) { break } } a;", 5},
+		{"var a = 0; var sum = 0; while (a < 5) { a = a + 1; if (a == 2) { continue } sum = sum + a } sum;", 13},
+		{"var n = 0; var i = 0; while (i < 3) { i = i + 1; var j = 0; while (true) { j = j + 1; if (j > i) { break } n = n + 1 } } n;", 6},
+		{"let f = fn() { var i = 0; while (true) { i = i + 1; if (i == 4) { return i * 10 } } }; f();", 40},
+		{"let f = fn() { return 1 }; var a = 0; while (a < 3) { a = a + f(); continue; a = 100 } a;", 3},
 	}
 
 	for _, tt := range tests {
 		testIntegerObject(t, testEval(tt.input), tt.expected)
 	}
 }
+
+func TestLoopsWithoutValue(t *testing.T) {
+	tests := []string{
+		"while (false) {}",
+		"var a = 0; while (a < 2) { a = a + 1; let b = a }",
+		"let f = fn() { while (false) {} }; f()",
+		"let f = fn() { while (false) {} }; [f()][0]",
+		"for (var i = 0; i < 0; i = i + 1) {}",
+		"for (var i = 0; i < 2; i = i + 1) {}",
+		"let f = fn() { for (var i = 0; false; i = i + 1) {} }; [f()][0]",
+	}
+
+	for _, input := range tests {
+		testNullObject(t, testEval(input))
+	}
+
+	input := `let f = fn() { while (false) {} }; "${[f()]}"`
+	if evaluated := testEval(input); evaluated.Inspect() != "[null]" {
+		t.Errorf("%s: got %s, want [null]", input, evaluated.Inspect())
+	}
+}
+
+func TestLoopControlInExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var n = 0; while (n < 5) { n = n + 1; let x = if (n == 2) { break } else { 1 }; } n", "2"},
+		{"var n = 0; var s = 0; while (n < 5) { n = n + 1; var x = if (n % 2 == 0) { continue } else { n }; s = s + x } s", "9"},
+		{"var n = 0; while (true) { n = if (n == 3) { break } else { n + 1 } } n", "3"},
+		{"var out = []; for (x in [1, 2, 3]) { out = push(out, if (x == 2) { continue } else { x }) } out", "[1, 3]"},
+		{`var n = 0; while (true) { n += 1; len(if (n == 3) { break } else { "ab" }) } n`, "3"},
+		{"let f = fn(a, b) { a }; var n = 0; while (true) { n += 1; f(n, b: if (n == 2) { break } else { n }) } n", "2"},
+		{"var n = 0; while (true) { n += 1; 1 + if (n == 3) { break } else { 0 } } n", "3"},
+		{"var n = 0; while (true) { n += 1; -if (n == 3) { break } else { 0 } } n", "3"},
+		{"var n = 0; while (true) { n += 1; [1][if (n == 3) { break } else { 0 }] } n", "3"},
+		{"var n = 0; while (true) { n += 1; [n, if (n == 3) { break } else { 0 }] } n", "3"},
+		{"var n = 0; while (true) { n += 1; {n: if (n == 3) { break } else { 0 }} } n", "3"},
+		{`var n = 0; while (true) { n += 1; "${if (n == 3) { break } else { 0 }}" } n`, "3"},
+		{"var h = {}; var n = 0; while (n < 3) { n += 1; h[n] = if (n == 2) { continue } else { n } } len([h[1], h[3]])", "2"},
+		{"var n = 0; for (x in [1, 2, 3]) { n += 1; let y = if (x == 1) { continue } else { x }; n += y } n", "8"},
+		{"let f = fn() { let x = if (true) { return 5 } else { 1 }; 10 }; f()", "5"},
+		{"let f = fn() { [1, if (true) { return 5 } else { 1 }]; 10 }; f()", "5"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: got %s, want %s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedPos string
+		expectedEnd string
+	}{
+		{"5 + true;", "1:1", "1:9"},
+		{"let a = 1;\nlet b = a + -true;", "2:13", "2:18"},
+		{"let a = 1;\n  foobar;", "2:3", "2:9"},
+		{"if (true) {\n\tlen(1)\n}", "2:2", "2:8"},
+		{"let f = fn(a, b) { a };\nf(1) + 1", "2:1", "2:5"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Pos.String() != tt.expectedPos {
+			t.Errorf("wrong error position. expected=%s, got=%s", tt.expectedPos, errObj.Pos)
+		}
+		if errObj.End.String() != tt.expectedEnd {
+			t.Errorf("wrong error end. expected=%s, got=%s", tt.expectedEnd, errObj.End)
+		}
+	}
+}
+
+func TestErrorStackTrace(t *testing.T) {
+	input := `let add = fn(a, b) {
+	a + b
+};
+let apply = fn(f) {
+	fn(x) { f(x, "one") }(1)
+};
+apply(add);`
+
+	evaluated := testEval(input)
+
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	expectedStack := []struct {
+		function string
+		pos      string
+	}{
+		{"add", "5:10"},
+		{"<anonymous>", "5:2"},
+		{"apply", "7:1"},
+	}
+
+	if len(errObj.Stack) != len(expectedStack) {
+		t.Fatalf("wrong stack depth. expected=%d, got=%d (%+v)", len(expectedStack), len(errObj.Stack), errObj.Stack)
+	}
+
+	for i, expected := range expectedStack {
+		frame := errObj.Stack[i]
+		if frame.Function != expected.function || frame.Pos.String() != expected.pos {
+			t.Errorf("stack[%d] wrong. expected=%s at %s, got=%s at %s", i, expected.function, expected.pos, frame.Function, frame.Pos)
+		}
+	}
+
+	expectedTrace := `Traceback (most recent call last):
+  at 7:1, in <main>
+  at 5:2, in apply
+  at 5:10, in <anonymous>
+  at 2:2, in add
+ERROR: type mismatch: INTEGER + STRING`
+	if errObj.Traceback() != expectedTrace {
+		t.Errorf("wrong traceback. expected=\n%s\ngot=\n%s", expectedTrace, errObj.Traceback())
+	}
+}
@@ -0,0 +1,41 @@
+package lexer
+
+import (
+	"fmt"
+	"monkey/token"
+)
+
+// ErrorKind classifies the errors reported by the lexer.
+type ErrorKind int
+
+const (
+	IllegalCharacter ErrorKind = iota
+	UnterminatedComment
+	UnterminatedString
+	InvalidEscape
+	InvalidUTF8
+	InvalidNumber
+)
+
+// Error is malformed input found by the lexer, spanning [Pos, End). The lexer
+// reports it and carries on with the next token.
+type Error struct {
+	Kind ErrorKind
+	Msg  string
+	Pos  token.Position
+	End  token.Position
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+// errorf records an error for the span [pos, end).
+func (l *Lexer) errorf(kind ErrorKind, pos token.Position, end token.Position, format string, a ...interface{}) {
+	l.errors = append(l.errors, Error{
+		Kind: kind,
+		Msg:  fmt.Sprintf(format, a...),
+		Pos:  pos,
+		End:  end,
+	})
+}
@@ -2,45 +2,131 @@ package lexer
 
 import (
 	"monkey/token"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Mode controls optional behavior of a Lexer.
+type Mode uint
+
+const (
+	// ScanComments makes the lexer return comments as COMMENT tokens
+	// instead of skipping them.
+	ScanComments Mode = 1 << iota
+
+	// SkipShebang makes the lexer skip a "#!" interpreter line at the very
+	// start of the input, as found in script files.
+	SkipShebang
 )
 
 type Lexer struct {
+	filename     string
 	input        string
+	mode         Mode
 	position     int
 	readPosition int
-	ch           byte
+	ch           rune // current character, utf8.RuneError for invalid UTF-8
+	line         int
+	column       int
+
+	// interpolations holds the strings whose embedded expressions are being
+	// lexed, innermost last.
+	interpolations []interpolation
+
+	errors []Error
+}
+
+// interpolation is an embedded expression ${...} of a string being lexed.
+type interpolation struct {
+	quote  token.Position // opening quote of the string
+	braces int            // braces opened and not yet closed within the expression
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	return NewWithFilename("", input)
+}
+
+// NewWithFilename returns a Lexer whose token positions refer to filename.
+func NewWithFilename(filename string, input string) *Lexer {
+	return NewWithMode(filename, input, 0)
+}
+
+// NewWithMode returns a Lexer like NewWithFilename with the given mode.
+func NewWithMode(filename string, input string, mode Mode) *Lexer {
+	l := &Lexer{filename: filename, input: input, mode: mode, line: 1}
 	l.readChar()
+	if mode&SkipShebang != 0 {
+		l.skipShebang()
+	}
 	return l
 }
 
+// Errors returns the errors found in the input read so far.
+func (l *Lexer) Errors() []Error {
+	return l.errors
+}
+
+// skipShebang skips a "#!" interpreter line at the very start of the input.
+func (l *Lexer) skipShebang() {
+	if l.ch != '#' || l.peekChar() != '!' {
+		return
+	}
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+}
+
 func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 
 	l.skipWhiteSpace()
+	for l.ch == '/' && (l.peekChar() == '/' || l.peekChar() == '*') {
+		pos := l.pos()
+		comment := l.readComment()
+		if l.mode&ScanComments != 0 {
+			return token.Token{Type: token.COMMENT, Literal: comment, Pos: pos, End: l.pos()}
+		}
+		l.skipWhiteSpace()
+	}
+
+	pos := l.pos()
 
 	switch l.ch {
 	case '=':
 		if l.peekChar() == '=' {
-			ch := l.ch
-			l.readChar()
-			literal := string(ch) + string(l.ch)
-			tok = token.Token{Type: token.EQ, Literal: literal}
+			tok = l.newTwoCharToken(token.EQ)
 		} else {
 			tok = newToken(token.ASSIGN, l.ch)
 		}
 	case '!':
 		if l.peekChar() == '=' {
-			ch := l.ch
-			l.readChar()
-			literal := string(ch) + string(l.ch)
-			tok = token.Token{Type: token.NOT_EQ, Literal: literal}
+			tok = l.newTwoCharToken(token.NOT_EQ)
 		} else {
 			tok = newToken(token.BANG, l.ch)
 		}
+	case '&':
+		if l.peekChar() == '&' {
+			tok = l.newTwoCharToken(token.AND)
+		} else {
+			tok = newToken(token.BIT_AND, l.ch)
+		}
+	case '|':
+		if l.peekChar() == '|' {
+			tok = l.newTwoCharToken(token.OR)
+		} else {
+			tok = newToken(token.BIT_OR, l.ch)
+		}
+	case '^':
+		tok = newToken(token.BIT_XOR, l.ch)
+	case '~':
+		tok = newToken(token.BIT_NOT, l.ch)
+	case '%':
+		if l.peekChar() == '=' {
+			tok = l.newTwoCharToken(token.PERCENT_ASSIGN)
+		} else {
+			tok = newToken(token.PERCENT, l.ch)
+		}
 	case '(':
 		tok = newToken(token.LPAREN, l.ch)
 	case ')':
@@ -52,69 +138,198 @@ func (l *Lexer) NextToken() token.Token {
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.newTwoCharToken(token.PLUS_ASSIGN)
+		} else {
+			tok = newToken(token.PLUS, l.ch)
+		}
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.newTwoCharToken(token.MINUS_ASSIGN)
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch)
+		if l.peekChar() == '*' {
+			tok = l.newTwoCharToken(token.POW)
+		} else if l.peekChar() == '=' {
+			tok = l.newTwoCharToken(token.ASTERISK_ASSIGN)
+		} else {
+			tok = newToken(token.ASTERISK, l.ch)
+		}
 	case '/':
-		tok = newToken(token.SLASH, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.newTwoCharToken(token.SLASH_ASSIGN)
+		} else {
+			tok = newToken(token.SLASH, l.ch)
+		}
 	case '<':
-		tok = newToken(token.LT, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.newTwoCharToken(token.LT_EQ)
+		} else if l.peekChar() == '<' {
+			tok = l.newTwoCharToken(token.SHL)
+		} else {
+			tok = newToken(token.LT, l.ch)
+		}
 	case '>':
-		tok = newToken(token.GT, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.newTwoCharToken(token.GT_EQ)
+		} else if l.peekChar() == '>' {
+			tok = l.newTwoCharToken(token.SHR)
+		} else {
+			tok = newToken(token.GT, l.ch)
+		}
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
 	case '{':
+		if n := len(l.interpolations); n > 0 {
+			l.interpolations[n-1].braces++
+		}
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
+		if n := len(l.interpolations); n > 0 && l.interpolations[n-1].braces == 0 {
+			// the end of an embedded expression; the string continues
+			quote := l.interpolations[n-1].quote
+			l.interpolations = l.interpolations[:n-1]
+			tok = l.readStringPart(quote, false)
+			break
+		}
+		if n := len(l.interpolations); n > 0 {
+			l.interpolations[n-1].braces--
+		}
 		tok = newToken(token.RBRACE, l.ch)
 	case '"':
-		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		tok = l.readStringPart(pos, true)
+	case '`':
+		tok = l.readRawString()
 	case 0:
+		if len(l.interpolations) > 0 {
+			l.errorf(UnterminatedString, l.interpolations[0].quote, pos, "unterminated string literal")
+			l.interpolations = nil
+		}
 		tok.Literal = ""
 		tok.Type = token.EOF
 	case ':':
 		tok = newToken(token.COLON, l.ch)
+	case '.':
+		if strings.HasPrefix(l.input[l.position:], "...") {
+			l.readChar()
+			l.readChar()
+			tok = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+		} else if l.peekChar() == '.' {
+			tok = l.newTwoCharToken(token.RANGE)
+		} else if isDigit(l.peekChar()) {
+			tok.Literal, tok.Type = l.readNumber()
+			tok.Pos, tok.End = pos, l.pos()
+			return tok
+		} else {
+			tok = l.illegalCharacter()
+		}
 	default:
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Pos, tok.End = pos, l.pos()
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Literal = l.readNumber()
-			tok.Type = token.INT
+			tok.Literal, tok.Type = l.readNumber()
+			tok.Pos, tok.End = pos, l.pos()
 			return tok
 		} else {
-			tok = newToken(token.ILLEGAL, l.ch)
+			tok = l.illegalCharacter()
 		}
 	}
 
 	l.readChar()
+	tok.Pos, tok.End = pos, l.pos()
 	return tok
 }
 
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
 
-// TODO Unicode
+// illegalCharacter reports the current character and returns it as an ILLEGAL
+// token. Invalid UTF-8 has been reported by readChar already.
+func (l *Lexer) illegalCharacter() token.Token {
+	literal := l.input[l.position:l.readPosition]
+	if !l.invalidUTF8() {
+		l.errorf(IllegalCharacter, l.pos(), l.nextPos(), "illegal character %q", literal)
+	}
+	return token.Token{Type: token.ILLEGAL, Literal: literal}
+}
+
+// newTwoCharToken consumes the current character and returns a token made of
+// it and the next one, which becomes the current character.
+func (l *Lexer) newTwoCharToken(tokenType token.TokenType) token.Token {
+	ch := l.ch
+	l.readChar()
+	return token.Token{Type: tokenType, Literal: string(ch) + string(l.ch)}
+}
+
+// readChar decodes the next UTF-8 character of the input. Invalid UTF-8 is
+// reported and read one byte at a time as utf8.RuneError. Columns count
+// characters, not bytes.
 func (l *Lexer) readChar() {
+	if l.readPosition > len(l.input) {
+		// already at EOF
+		return
+	}
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+
+	width := 1
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
-		l.ch = l.input[l.readPosition]
+		l.ch, width = utf8.DecodeRuneInString(l.input[l.readPosition:])
 	}
 	l.position = l.readPosition
-	l.readPosition++
+	l.readPosition += width
+	l.column++
+
+	if l.invalidUTF8() {
+		l.errorf(InvalidUTF8, l.pos(), l.nextPos(), "invalid UTF-8 encoding")
+	}
+}
+
+// invalidUTF8 reports whether the current character is a byte of invalid
+// UTF-8 rather than a character of the input.
+func (l *Lexer) invalidUTF8() bool {
+	return l.ch == utf8.RuneError && l.readPosition-l.position == 1
 }
 
-func (l *Lexer) peekChar() byte {
+// pos returns the position of the current character.
+func (l *Lexer) pos() token.Position {
+	return token.Position{
+		Filename: l.filename,
+		Offset:   l.position,
+		Line:     l.line,
+		Column:   l.column,
+	}
+}
+
+// nextPos returns the position following the current character.
+func (l *Lexer) nextPos() token.Position {
+	pos := l.pos()
+	pos.Offset = l.readPosition
+	if l.ch == '\n' {
+		pos.Line++
+		pos.Column = 1
+	} else {
+		pos.Column++
+	}
+	return pos
+}
+
+func (l *Lexer) peekChar() rune {
 	if l.readPosition >= len(l.input) {
 		return 0
 	}
-	return l.input[l.readPosition]
+	ch, _ := utf8.DecodeRuneInString(l.input[l.readPosition:])
+	return ch
 }
 
 func (l *Lexer) readIdentifier() string {
@@ -125,19 +340,146 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[position:l.position]
 }
 
-func (l *Lexer) readNumber() string {
+// readNumber reads an integer, or a float when a fraction or an exponent
+// follows. A "." only starts a fraction when a digit comes after it. Digits
+// may be separated by '_', and a leading 0 makes an integer octal. A
+// malformed number is reported and returned as an ILLEGAL token.
+func (l *Lexer) readNumber() (string, token.TokenType) {
+	if l.ch == '0' && basePrefix(l.peekChar()) != 0 {
+		return l.readPrefixedInteger()
+	}
+
 	position := l.position
-	for isDigit(l.ch) {
+	pos := l.pos()
+	tokType := token.TokenType(token.INT)
+
+	valid := l.readDigits(isDigit)
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokType = token.FLOAT
 		l.readChar()
+		valid = l.readDigits(isDigit) && valid
+	}
+	if (l.ch == 'e' || l.ch == 'E') && l.exponentFollows() {
+		tokType = token.FLOAT
+		l.readChar()
+		if l.ch == '+' || l.ch == '-' {
+			l.readChar()
+		}
+		valid = l.readDigits(isDigit) && valid
+	}
+
+	literal := l.input[position:l.position]
+	if valid && tokType == token.INT && len(literal) > 1 && literal[0] == '0' {
+		valid = l.checkDigits(pos, literal, 1, 8, "octal")
+	}
+	if !valid {
+		return literal, token.ILLEGAL
+	}
+	return literal, tokType
+}
+
+// readPrefixedInteger reads an integer with a 0x, 0o or 0b base prefix.
+func (l *Lexer) readPrefixedInteger() (string, token.TokenType) {
+	position := l.position
+	pos := l.pos()
+
+	l.readChar()
+	base := basePrefix(l.ch)
+	l.readChar()
+
+	name, digit := "hexadecimal", isHexDigit
+	switch base {
+	case 8:
+		name, digit = "octal", isDigit
+	case 2:
+		name, digit = "binary", isDigit
+	}
+
+	// a separator may follow the prefix, as in 0x_FF
+	if l.ch == '_' {
+		l.readChar()
+	}
+	start := l.position
+	valid := l.readDigits(digit)
+
+	literal := l.input[position:l.position]
+	if l.position == start {
+		l.errorf(InvalidNumber, pos, l.pos(), "%s literal has no digits", name)
+		return literal, token.ILLEGAL
+	}
+	if !valid || !l.checkDigits(pos, literal, start-position, base, name) {
+		return literal, token.ILLEGAL
+	}
+	return literal, token.INT
+}
+
+// basePrefix returns the base the character following a leading 0 selects,
+// or 0 if it is not a base prefix.
+func basePrefix(ch rune) int {
+	switch ch {
+	case 'x', 'X':
+		return 16
+	case 'o', 'O':
+		return 8
+	case 'b', 'B':
+		return 2
+	default:
+		return 0
 	}
-	return l.input[position:l.position]
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+// checkDigits reports the first digit of literal from index start on that is
+// not valid in base. The literal starts at pos.
+func (l *Lexer) checkDigits(pos token.Position, literal string, start int, base int, name string) bool {
+	for i := start; i < len(literal); i++ {
+		ch := rune(literal[i])
+		if ch == '_' || hexValue(ch) < base {
+			continue
+		}
+		at := pos
+		at.Offset += i
+		at.Column += i
+		end := at
+		end.Offset++
+		end.Column++
+		l.errorf(InvalidNumber, at, end, "invalid digit '%c' in %s literal", ch, name)
+		return false
+	}
+	return true
+}
+
+// exponentFollows reports whether an optionally signed digit follows the current "e".
+func (l *Lexer) exponentFollows() bool {
+	rest := l.input[l.readPosition:]
+	if len(rest) > 0 && (rest[0] == '+' || rest[0] == '-') {
+		rest = rest[1:]
+	}
+	return len(rest) > 0 && isDigit(rune(rest[0]))
+}
+
+// readDigits reads the digits accepted by digit and the '_' separators
+// between them. A separator that does not stand between two digits is
+// reported, and false is returned.
+func (l *Lexer) readDigits(digit func(rune) bool) bool {
+	valid := true
+	afterDigit := false
+	for digit(l.ch) || l.ch == '_' {
+		if l.ch == '_' && valid && (!afterDigit || !digit(l.peekChar())) {
+			l.errorf(InvalidNumber, l.pos(), l.nextPos(), "'_' must separate successive digits")
+			valid = false
+		}
+		afterDigit = l.ch != '_'
+		l.readChar()
+	}
+	return valid
+}
+
+func isLetter(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' ||
+		ch >= utf8.RuneSelf && unicode.IsLetter(ch)
 }
 
-func isDigit(ch byte) bool {
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
@@ -146,14 +488,177 @@ func (l *Lexer) skipWhiteSpace() {
 		l.readChar()
 	}
 }
-func (l *Lexer) readString() string {
-	position := l.position + 1
+
+// readComment reads a line comment up to the end of the line, or a block
+// comment including the comments nested in it. An unterminated block comment
+// is reported and extends to the end of the input.
+func (l *Lexer) readComment() string {
+	position := l.position
+
+	if l.peekChar() == '/' {
+		for l.ch != '\n' && l.ch != 0 {
+			l.readChar()
+		}
+		return l.input[position:l.position]
+	}
+
+	pos := l.pos()
+	l.readChar()
+	l.readChar()
+	for depth := 1; depth > 0; l.readChar() {
+		switch {
+		case l.ch == 0:
+			l.errorf(UnterminatedComment, pos, l.pos(), "unterminated block comment")
+			return l.input[position:l.position]
+		case l.ch == '/' && l.peekChar() == '*':
+			depth++
+			l.readChar()
+		case l.ch == '*' && l.peekChar() == '/':
+			depth--
+			l.readChar()
+		}
+	}
+	return l.input[position:l.position]
+}
+
+// readRawString reads a backquoted string, which is taken verbatim and may
+// span lines, leaving the closing quote as the current character.
+func (l *Lexer) readRawString() token.Token {
+	position := l.position
+	pos := l.pos()
 
 	for {
 		l.readChar()
-		if l.ch == '"' || l.ch == 0 {
-			break
+		switch l.ch {
+		case '`':
+			return token.Token{Type: token.STRING, Literal: l.input[position+1 : l.position]}
+		case 0:
+			l.errorf(UnterminatedString, pos, l.pos(), "unterminated string literal")
+			return token.Token{Type: token.ILLEGAL, Literal: l.input[position:l.position]}
 		}
 	}
-	return l.input[position:l.position]
+}
+
+// readStringPart reads a double-quoted string with its escape sequences
+// replaced, starting after the current character: the opening quote at quote
+// when head is set, or else the } closing an embedded expression. It stops
+// at the closing quote or at the next "${", which starts an embedded
+// expression, leaving the last character read as the current one.
+//
+// A string without embedded expressions is a STRING token. Otherwise its text
+// is split into an INTERP_BEGIN token, INTERP_MIDDLE tokens and an INTERP_END
+// token around the tokens of the expressions. An unterminated string, or a
+// STRING with invalid escapes, is reported and returned as an ILLEGAL token.
+func (l *Lexer) readStringPart(quote token.Position, head bool) token.Token {
+	position := l.position
+	valid := true
+
+	var out strings.Builder
+	for {
+		l.readChar()
+		switch {
+		case l.ch == '"':
+			if !head {
+				return token.Token{Type: token.INTERP_END, Literal: out.String()}
+			}
+			if !valid {
+				return token.Token{Type: token.ILLEGAL, Literal: l.input[position:l.readPosition]}
+			}
+			return token.Token{Type: token.STRING, Literal: out.String()}
+		case l.ch == '$' && l.peekChar() == '{':
+			l.readChar()
+			l.interpolations = append(l.interpolations, interpolation{quote: quote})
+			if head {
+				return token.Token{Type: token.INTERP_BEGIN, Literal: out.String()}
+			}
+			return token.Token{Type: token.INTERP_MIDDLE, Literal: out.String()}
+		case l.ch == 0:
+			l.errorf(UnterminatedString, quote, l.pos(), "unterminated string literal")
+			return token.Token{Type: token.ILLEGAL, Literal: l.input[position:l.position]}
+		case l.ch == '\\':
+			if !l.readEscape(&out) {
+				valid = false
+			}
+		default:
+			// invalid UTF-8 is kept as is
+			out.WriteString(l.input[l.position:l.readPosition])
+		}
+	}
+}
+
+// readEscape reads the escape sequence starting at the current backslash and
+// writes the character it stands for to out. The last character of the
+// sequence becomes the current one.
+func (l *Lexer) readEscape(out *strings.Builder) bool {
+	pos := l.pos()
+
+	l.readChar()
+	switch l.ch {
+	case 'n':
+		out.WriteByte('\n')
+	case 't':
+		out.WriteByte('\t')
+	case 'r':
+		out.WriteByte('\r')
+	case '\\', '"', '$':
+		out.WriteRune(l.ch)
+	case 'x':
+		value, n := l.readHexDigits(2)
+		if n != 2 {
+			l.errorf(InvalidEscape, pos, l.nextPos(), "invalid escape sequence: \\x must be followed by 2 hexadecimal digits")
+			return false
+		}
+		out.WriteByte(byte(value))
+	case 'u':
+		if l.peekChar() != '{' {
+			l.errorf(InvalidEscape, pos, l.nextPos(), "invalid escape sequence: \\u must be followed by {hexadecimal code point}")
+			return false
+		}
+		l.readChar()
+		value, n := l.readHexDigits(6)
+		if n == 0 || l.peekChar() != '}' {
+			l.errorf(InvalidEscape, pos, l.nextPos(), "invalid escape sequence: \\u must be followed by {hexadecimal code point}")
+			return false
+		}
+		l.readChar()
+		if !utf8.ValidRune(rune(value)) {
+			l.errorf(InvalidEscape, pos, l.nextPos(), "invalid escape sequence: U+%X is not a valid code point", value)
+			return false
+		}
+		out.WriteRune(rune(value))
+	case 0:
+		// the caller reports the unterminated string
+	default:
+		l.errorf(InvalidEscape, pos, l.nextPos(), "unknown escape sequence \\%c", l.ch)
+		return false
+	}
+
+	return true
+}
+
+// readHexDigits reads up to max hexadecimal digits following the current
+// character and returns their value and how many there were.
+func (l *Lexer) readHexDigits(max int) (int, int) {
+	value, n := 0, 0
+	for n < max && isHexDigit(l.peekChar()) {
+		l.readChar()
+		value = value*16 + hexValue(l.ch)
+		n++
+	}
+	return value, n
+}
+
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+func hexValue(ch rune) int {
+	switch {
+	case isDigit(ch):
+		return int(ch - '0')
+	case 'a' <= ch && ch <= 'f':
+		return int(ch-'a') + 10
+	default:
+		return int(ch-'A') + 10
+	}
 }
@@ -16,7 +16,7 @@ let add = fn(x, y) {
 };
 
 let result = add(five, ten);
-!-/*5;
+!-/ *5;
 5 < 10 > 5
 
 if (5 < 10) {
@@ -36,6 +36,12 @@ if (5 < 10) {
 for (var i = 0; i < 10; i = i+1) {
 	puts(i);
 }
+a <= b >= c && d || e
+a % b ** c & d | e ^ ~f << g >> h
+fn(...xs)
+while (x) { break; continue; }
+for (i in 0..10) {}
+a += 1 -= 2 *= 3 /= 4 %= 5
 `
 
 	tests := []struct {
@@ -93,7 +99,7 @@ for (var i = 0; i < 10; i = i+1) {
 		{token.RPAREN, ")"},
 		{token.SEMICOLON, ";"},
 
-		// !-/*5;
+		// !-/ *5;
 		{token.BANG, "!"},
 		{token.MINUS, "-"},
 		{token.SLASH, "/"},
@@ -189,6 +195,79 @@ for (var i = 0; i < 10; i = i+1) {
 		{token.SEMICOLON, ";"},
 		{token.RBRACE, "}"},
 
+		// a <= b >= c && d || e
+		{token.IDENT, "a"},
+		{token.LT_EQ, "<="},
+		{token.IDENT, "b"},
+		{token.GT_EQ, ">="},
+		{token.IDENT, "c"},
+		{token.AND, "&&"},
+		{token.IDENT, "d"},
+		{token.OR, "||"},
+		{token.IDENT, "e"},
+
+		// a % b ** c & d | e ^ ~f << g >> h
+		{token.IDENT, "a"},
+		{token.PERCENT, "%"},
+		{token.IDENT, "b"},
+		{token.POW, "**"},
+		{token.IDENT, "c"},
+		{token.BIT_AND, "&"},
+		{token.IDENT, "d"},
+		{token.BIT_OR, "|"},
+		{token.IDENT, "e"},
+		{token.BIT_XOR, "^"},
+		{token.BIT_NOT, "~"},
+		{token.IDENT, "f"},
+		{token.SHL, "<<"},
+		{token.IDENT, "g"},
+		{token.SHR, ">>"},
+		{token.IDENT, "h"},
+
+		// fn(...xs)
+		{token.FUNCTION, "fn"},
+		{token.LPAREN, "("},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "xs"},
+		{token.RPAREN, ")"},
+
+		// while (x) { break; continue; }
+		{token.WHILE, "while"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.BREAK, "break"},
+		{token.SEMICOLON, ";"},
+		{token.CONTINUE, "continue"},
+		{token.SEMICOLON, ";"},
+		{token.RBRACE, "}"},
+
+		// for (i in 0..10) {}
+		{token.FOR, "for"},
+		{token.LPAREN, "("},
+		{token.IDENT, "i"},
+		{token.IN, "in"},
+		{token.INT, "0"},
+		{token.RANGE, ".."},
+		{token.INT, "10"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
+
+		// a += 1 -= 2 *= 3 /= 4 %= 5
+		{token.IDENT, "a"},
+		{token.PLUS_ASSIGN, "+="},
+		{token.INT, "1"},
+		{token.MINUS_ASSIGN, "-="},
+		{token.INT, "2"},
+		{token.ASTERISK_ASSIGN, "*="},
+		{token.INT, "3"},
+		{token.SLASH_ASSIGN, "/="},
+		{token.INT, "4"},
+		{token.PERCENT_ASSIGN, "%="},
+		{token.INT, "5"},
+
 		{token.EOF, ""},
 	}
 
@@ -206,3 +285,408 @@ for (var i = 0; i < 10; i = i+1) {
 		}
 	}
 }
+
+func TestNumberLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []token.Token
+	}{
+		{"42", []token.Token{{Type: token.INT, Literal: "42"}}},
+		{"3.14", []token.Token{{Type: token.FLOAT, Literal: "3.14"}}},
+		{".5", []token.Token{{Type: token.FLOAT, Literal: ".5"}}},
+		{"1e-9", []token.Token{{Type: token.FLOAT, Literal: "1e-9"}}},
+		{"2.5E+3", []token.Token{{Type: token.FLOAT, Literal: "2.5E+3"}}},
+		{"7e3", []token.Token{{Type: token.FLOAT, Literal: "7e3"}}},
+		{"1e", []token.Token{{Type: token.INT, Literal: "1"}, {Type: token.IDENT, Literal: "e"}}},
+		{"1e+", []token.Token{{Type: token.INT, Literal: "1"}, {Type: token.IDENT, Literal: "e"}, {Type: token.PLUS, Literal: "+"}}},
+		{"1.", []token.Token{{Type: token.INT, Literal: "1"}, {Type: token.ILLEGAL, Literal: "."}}},
+		{"0xFF", []token.Token{{Type: token.INT, Literal: "0xFF"}}},
+		{"0XdeadBEEF", []token.Token{{Type: token.INT, Literal: "0XdeadBEEF"}}},
+		{"0o755", []token.Token{{Type: token.INT, Literal: "0o755"}}},
+		{"0b1010", []token.Token{{Type: token.INT, Literal: "0b1010"}}},
+		{"0755", []token.Token{{Type: token.INT, Literal: "0755"}}},
+		{"1_000_000", []token.Token{{Type: token.INT, Literal: "1_000_000"}}},
+		{"0x_FF_FF", []token.Token{{Type: token.INT, Literal: "0x_FF_FF"}}},
+		{"1_000.000_1e1_0", []token.Token{{Type: token.FLOAT, Literal: "1_000.000_1e1_0"}}},
+		{"09.5", []token.Token{{Type: token.FLOAT, Literal: "09.5"}}},
+		{"0b1.5", []token.Token{{Type: token.INT, Literal: "0b1"}, {Type: token.FLOAT, Literal: ".5"}}},
+		{"_1", []token.Token{{Type: token.IDENT, Literal: "_"}, {Type: token.INT, Literal: "1"}}},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		for i, expected := range tt.expected {
+			tok := l.NextToken()
+			if tok.Type != expected.Type || tok.Literal != expected.Literal {
+				t.Errorf("%q: tokens[%d] wrong. expected=%s %q, got=%s %q",
+					tt.input, i, expected.Type, expected.Literal, tok.Type, tok.Literal)
+			}
+		}
+		if tok := l.NextToken(); tok.Type != token.EOF {
+			t.Errorf("%q: expected EOF, got=%s %q", tt.input, tok.Type, tok.Literal)
+		}
+		if len(l.Errors()) != 0 && tt.expected[len(tt.expected)-1].Type != token.ILLEGAL {
+			t.Errorf("%q: unexpected errors %q", tt.input, l.Errors())
+		}
+	}
+}
+
+func TestMalformedNumbers(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedLiteral string
+		expectedError   string
+	}{
+		{"0x", "0x", "1:1: hexadecimal literal has no digits"},
+		{"0b_", "0b_", "1:1: binary literal has no digits"},
+		{"0o", "0o", "1:1: octal literal has no digits"},
+		{"1__0", "1__0", "1:2: '_' must separate successive digits"},
+		{"100_", "100_", "1:4: '_' must separate successive digits"},
+		{"1_.5", "1_.5", "1:2: '_' must separate successive digits"},
+		{"0x__1", "0x__1", "1:4: '_' must separate successive digits"},
+		{"0o78", "0o78", "1:4: invalid digit '8' in octal literal"},
+		{"0b102", "0b102", "1:5: invalid digit '2' in binary literal"},
+		{"0_789", "0_789", "1:4: invalid digit '8' in octal literal"},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+		if tok.Type != token.ILLEGAL || tok.Literal != tt.expectedLiteral {
+			t.Errorf("%q: token wrong. expected=ILLEGAL %q, got=%s %q", tt.input, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+		if tok := l.NextToken(); tok.Type != token.EOF {
+			t.Errorf("%q: expected EOF, got=%s %q", tt.input, tok.Type, tok.Literal)
+		}
+
+		if len(l.Errors()) != 1 {
+			t.Errorf("%q: expected 1 error, got %d (%q)", tt.input, len(l.Errors()), l.Errors())
+			continue
+		}
+		if e := l.Errors()[0]; e.Kind != InvalidNumber || e.Error() != tt.expectedError {
+			t.Errorf("%q: error is %q, want %q", tt.input, e.Error(), tt.expectedError)
+		}
+	}
+}
+
+func TestStringLiterals(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedType   token.TokenType
+		expected       string
+		expectedErrors []string
+	}{
+		{`"plain"`, token.STRING, "plain", nil},
+		{`"a\nb\tc\rd"`, token.STRING, "a\nb\tc\rd", nil},
+		{`"say \"hi\" \\o/"`, token.STRING, `say "hi" \o/`, nil},
+		{`"\x41\x6a"`, token.STRING, "Aj", nil},
+		{`"\u{1F600} \u{e9}"`, token.STRING, "\U0001F600 \u00e9", nil},
+		{"`raw \\n\nline`", token.STRING, "raw \\n\nline", nil},
+		{"`say \"hi\"`", token.STRING, `say "hi"`, nil},
+		{`"abc`, token.ILLEGAL, `"abc`, []string{"1:1: unterminated string literal"}},
+		{"`abc", token.ILLEGAL, "`abc", []string{"1:1: unterminated string literal"}},
+		{`"a\"`, token.ILLEGAL, `"a\"`, []string{"1:1: unterminated string literal"}},
+		{`"a\qb\x4"`, token.ILLEGAL, `"a\qb\x4"`, []string{
+			"1:3: unknown escape sequence \\q",
+			"1:6: invalid escape sequence: \\x must be followed by 2 hexadecimal digits",
+		}},
+		{`"\u41"`, token.ILLEGAL, `"\u41"`, []string{"1:2: invalid escape sequence: \\u must be followed by {hexadecimal code point}"}},
+		{`"\u{41"`, token.ILLEGAL, `"\u{41"`, []string{"1:2: invalid escape sequence: \\u must be followed by {hexadecimal code point}"}},
+		{`"\u{110000}"`, token.ILLEGAL, `"\u{110000}"`, []string{"1:2: invalid escape sequence: U+110000 is not a valid code point"}},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expected {
+			t.Errorf("%s: token wrong. expected=%s %q, got=%s %q", tt.input, tt.expectedType, tt.expected, tok.Type, tok.Literal)
+		}
+		if tok := l.NextToken(); tok.Type != token.EOF {
+			t.Errorf("%s: expected EOF, got=%s %q", tt.input, tok.Type, tok.Literal)
+		}
+
+		if len(l.Errors()) != len(tt.expectedErrors) {
+			t.Errorf("%s: wrong number of errors. expected=%d, got=%d (%q)", tt.input, len(tt.expectedErrors), len(l.Errors()), l.Errors())
+			continue
+		}
+		for i, expected := range tt.expectedErrors {
+			if l.Errors()[i].Error() != expected {
+				t.Errorf("%s: errors[%d] is %q, want %q", tt.input, i, l.Errors()[i].Error(), expected)
+			}
+		}
+	}
+}
+
+func TestInterpolatedStrings(t *testing.T) {
+	tests := []struct {
+		input          string
+		expected       []token.Token
+		expectedErrors []string
+	}{
+		{`"a${x}b"`, []token.Token{
+			{Type: token.INTERP_BEGIN, Literal: "a"},
+			{Type: token.IDENT, Literal: "x"},
+			{Type: token.INTERP_END, Literal: "b"},
+		}, nil},
+		{`"${x}${y}"`, []token.Token{
+			{Type: token.INTERP_BEGIN, Literal: ""},
+			{Type: token.IDENT, Literal: "x"},
+			{Type: token.INTERP_MIDDLE, Literal: ""},
+			{Type: token.IDENT, Literal: "y"},
+			{Type: token.INTERP_END, Literal: ""},
+		}, nil},
+		{`"n: ${h["k"]}!\n"`, []token.Token{
+			{Type: token.INTERP_BEGIN, Literal: "n: "},
+			{Type: token.IDENT, Literal: "h"},
+			{Type: token.LBRACKET, Literal: "["},
+			{Type: token.STRING, Literal: "k"},
+			{Type: token.RBRACKET, Literal: "]"},
+			{Type: token.INTERP_END, Literal: "!\n"},
+		}, nil},
+		{`"${ {1: "}"}[1] }"`, []token.Token{
+			{Type: token.INTERP_BEGIN, Literal: ""},
+			{Type: token.LBRACE, Literal: "{"},
+			{Type: token.INT, Literal: "1"},
+			{Type: token.COLON, Literal: ":"},
+			{Type: token.STRING, Literal: "}"},
+			{Type: token.RBRACE, Literal: "}"},
+			{Type: token.LBRACKET, Literal: "["},
+			{Type: token.INT, Literal: "1"},
+			{Type: token.RBRACKET, Literal: "]"},
+			{Type: token.INTERP_END, Literal: ""},
+		}, nil},
+		{`"a${"b${c}"}"`, []token.Token{
+			{Type: token.INTERP_BEGIN, Literal: "a"},
+			{Type: token.INTERP_BEGIN, Literal: "b"},
+			{Type: token.IDENT, Literal: "c"},
+			{Type: token.INTERP_END, Literal: ""},
+			{Type: token.INTERP_END, Literal: ""},
+		}, nil},
+		{`"\${x} $x {x}"`, []token.Token{{Type: token.STRING, Literal: "${x} $x {x}"}}, nil},
+		{"`${x}`", []token.Token{{Type: token.STRING, Literal: "${x}"}}, nil},
+		{`"a ${x`, []token.Token{
+			{Type: token.INTERP_BEGIN, Literal: "a "},
+			{Type: token.IDENT, Literal: "x"},
+		}, []string{"1:1: unterminated string literal"}},
+		{`"a ${x} b`, []token.Token{
+			{Type: token.INTERP_BEGIN, Literal: "a "},
+			{Type: token.IDENT, Literal: "x"},
+			{Type: token.ILLEGAL, Literal: "} b"},
+		}, []string{"1:1: unterminated string literal"}},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		for i, expected := range tt.expected {
+			tok := l.NextToken()
+			if tok.Type != expected.Type || tok.Literal != expected.Literal {
+				t.Errorf("%s: tokens[%d] wrong. expected=%s %q, got=%s %q",
+					tt.input, i, expected.Type, expected.Literal, tok.Type, tok.Literal)
+			}
+		}
+		if tok := l.NextToken(); tok.Type != token.EOF {
+			t.Errorf("%s: expected EOF, got=%s %q", tt.input, tok.Type, tok.Literal)
+		}
+
+		if len(l.Errors()) != len(tt.expectedErrors) {
+			t.Errorf("%s: wrong number of errors. expected=%d, got=%d (%q)", tt.input, len(tt.expectedErrors), len(l.Errors()), l.Errors())
+			continue
+		}
+		for i, expected := range tt.expectedErrors {
+			if l.Errors()[i].Error() != expected {
+				t.Errorf("%s: errors[%d] is %q, want %q", tt.input, i, l.Errors()[i].Error(), expected)
+			}
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	tests := []struct {
+		input          string
+		mode           Mode
+		expected       []token.Token
+		expectedErrors []string
+	}{
+		{"a // b\nc", 0, []token.Token{{Type: token.IDENT, Literal: "a"}, {Type: token.IDENT, Literal: "c"}}, nil},
+		{"a /* b */ c", 0, []token.Token{{Type: token.IDENT, Literal: "a"}, {Type: token.IDENT, Literal: "c"}}, nil},
+		{"a /* b /* c */ d */ e", 0, []token.Token{{Type: token.IDENT, Literal: "a"}, {Type: token.IDENT, Literal: "e"}}, nil},
+		{"/**/a/* // */", 0, []token.Token{{Type: token.IDENT, Literal: "a"}}, nil},
+		{"a / b /= c", 0, []token.Token{
+			{Type: token.IDENT, Literal: "a"},
+			{Type: token.SLASH, Literal: "/"},
+			{Type: token.IDENT, Literal: "b"},
+			{Type: token.SLASH_ASSIGN, Literal: "/="},
+			{Type: token.IDENT, Literal: "c"},
+		}, nil},
+		{"a /* b /* c */", 0, []token.Token{{Type: token.IDENT, Literal: "a"}}, []string{"1:3: unterminated block comment"}},
+		{"a // b\n/* c\n d */ e", ScanComments, []token.Token{
+			{Type: token.IDENT, Literal: "a"},
+			{Type: token.COMMENT, Literal: "// b"},
+			{Type: token.COMMENT, Literal: "/* c\n d */"},
+			{Type: token.IDENT, Literal: "e"},
+		}, nil},
+		{"/* a", ScanComments, []token.Token{{Type: token.COMMENT, Literal: "/* a"}}, []string{"1:1: unterminated block comment"}},
+	}
+
+	for _, tt := range tests {
+		l := NewWithMode("", tt.input, tt.mode)
+		for i, expected := range tt.expected {
+			tok := l.NextToken()
+			if tok.Type != expected.Type || tok.Literal != expected.Literal {
+				t.Errorf("%q: tokens[%d] wrong. expected=%s %q, got=%s %q",
+					tt.input, i, expected.Type, expected.Literal, tok.Type, tok.Literal)
+			}
+		}
+		if tok := l.NextToken(); tok.Type != token.EOF {
+			t.Errorf("%q: expected EOF, got=%s %q", tt.input, tok.Type, tok.Literal)
+		}
+
+		if len(l.Errors()) != len(tt.expectedErrors) {
+			t.Errorf("%q: wrong number of errors. expected=%d, got=%d (%q)", tt.input, len(tt.expectedErrors), len(l.Errors()), l.Errors())
+			continue
+		}
+		for i, expected := range tt.expectedErrors {
+			if l.Errors()[i].Error() != expected {
+				t.Errorf("%q: errors[%d] is %q, want %q", tt.input, i, l.Errors()[i].Error(), expected)
+			}
+		}
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := "let x = 5;\n  \"ab\" + x\n"
+
+	tests := []struct {
+		expectedType token.TokenType
+		expectedPos  token.Position
+		expectedEnd  token.Position
+	}{
+		{token.LET, token.Position{Filename: "test.mk", Offset: 0, Line: 1, Column: 1}, token.Position{Filename: "test.mk", Offset: 3, Line: 1, Column: 4}},
+		{token.IDENT, token.Position{Filename: "test.mk", Offset: 4, Line: 1, Column: 5}, token.Position{Filename: "test.mk", Offset: 5, Line: 1, Column: 6}},
+		{token.ASSIGN, token.Position{Filename: "test.mk", Offset: 6, Line: 1, Column: 7}, token.Position{Filename: "test.mk", Offset: 7, Line: 1, Column: 8}},
+		{token.INT, token.Position{Filename: "test.mk", Offset: 8, Line: 1, Column: 9}, token.Position{Filename: "test.mk", Offset: 9, Line: 1, Column: 10}},
+		{token.SEMICOLON, token.Position{Filename: "test.mk", Offset: 9, Line: 1, Column: 10}, token.Position{Filename: "test.mk", Offset: 10, Line: 1, Column: 11}},
+		{token.STRING, token.Position{Filename: "test.mk", Offset: 13, Line: 2, Column: 3}, token.Position{Filename: "test.mk", Offset: 17, Line: 2, Column: 7}},
+		{token.PLUS, token.Position{Filename: "test.mk", Offset: 18, Line: 2, Column: 8}, token.Position{Filename: "test.mk", Offset: 19, Line: 2, Column: 9}},
+		{token.IDENT, token.Position{Filename: "test.mk", Offset: 20, Line: 2, Column: 10}, token.Position{Filename: "test.mk", Offset: 21, Line: 2, Column: 11}},
+		{token.EOF, token.Position{Filename: "test.mk", Offset: 22, Line: 3, Column: 1}, token.Position{Filename: "test.mk", Offset: 22, Line: 3, Column: 1}},
+		{token.EOF, token.Position{Filename: "test.mk", Offset: 22, Line: 3, Column: 1}, token.Position{Filename: "test.mk", Offset: 22, Line: 3, Column: 1}},
+	}
+
+	l := NewWithFilename("test.mk", input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Pos != tt.expectedPos {
+			t.Errorf("tests[%d] - pos wrong. expected=%+v, got=%+v", i, tt.expectedPos, tok.Pos)
+		}
+
+		if tok.End != tt.expectedEnd {
+			t.Errorf("tests[%d] - end wrong. expected=%+v, got=%+v", i, tt.expectedEnd, tok.End)
+		}
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	input := "let café = \"日本語\";\nラベル + café_2 ¬"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedPos     string
+		expectedEnd     string
+	}{
+		{token.LET, "let", "1:1", "1:4"},
+		{token.IDENT, "café", "1:5", "1:9"},
+		{token.ASSIGN, "=", "1:10", "1:11"},
+		{token.STRING, "日本語", "1:12", "1:17"},
+		{token.SEMICOLON, ";", "1:17", "1:18"},
+		{token.IDENT, "ラベル", "2:1", "2:4"},
+		{token.PLUS, "+", "2:5", "2:6"},
+		{token.IDENT, "café_", "2:7", "2:12"},
+		{token.INT, "2", "2:12", "2:13"},
+		{token.ILLEGAL, "¬", "2:14", "2:15"},
+		{token.EOF, "", "2:15", "2:15"},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token wrong. expected=%s %q, got=%s %q",
+				i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+		if tok.Pos.String() != tt.expectedPos || tok.End.String() != tt.expectedEnd {
+			t.Errorf("tests[%d] - span wrong. expected=%s-%s, got=%s-%s",
+				i, tt.expectedPos, tt.expectedEnd, tok.Pos, tok.End)
+		}
+	}
+
+	if len(l.Errors()) != 1 || l.Errors()[0].Error() != `2:14: illegal character "¬"` {
+		t.Errorf("wrong errors. got=%q", l.Errors())
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	input := "a \xff\xfe b \"c\xffd\" // \xff\n"
+
+	l := New(input)
+
+	expected := []token.Token{
+		{Type: token.IDENT, Literal: "a"},
+		{Type: token.ILLEGAL, Literal: "\xff"},
+		{Type: token.ILLEGAL, Literal: "\xfe"},
+		{Type: token.IDENT, Literal: "b"},
+		{Type: token.STRING, Literal: "c\xffd"},
+		{Type: token.EOF, Literal: ""},
+	}
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt.Type || tok.Literal != tt.Literal {
+			t.Fatalf("tokens[%d] wrong. expected=%s %q, got=%s %q", i, tt.Type, tt.Literal, tok.Type, tok.Literal)
+		}
+	}
+
+	expectedErrors := []string{
+		"1:3: invalid UTF-8 encoding",
+		"1:4: invalid UTF-8 encoding",
+		"1:10: invalid UTF-8 encoding",
+		"1:17: invalid UTF-8 encoding",
+	}
+	if len(l.Errors()) != len(expectedErrors) {
+		t.Fatalf("wrong number of errors. expected=%d, got=%d (%q)", len(expectedErrors), len(l.Errors()), l.Errors())
+	}
+	for i, expected := range expectedErrors {
+		if l.Errors()[i].Error() != expected || l.Errors()[i].Kind != InvalidUTF8 {
+			t.Errorf("errors[%d] is %q, want %q", i, l.Errors()[i].Error(), expected)
+		}
+	}
+}
+
+func TestShebangLine(t *testing.T) {
+	input := "#!/usr/bin/env monkey run\nputs(1);"
+
+	l := NewWithMode("", input, SkipShebang)
+
+	tok := l.NextToken()
+	if tok.Type != token.IDENT || tok.Literal != "puts" {
+		t.Fatalf("first token wrong. expected=IDENT(puts), got=%s(%q)", tok.Type, tok.Literal)
+	}
+	if tok.Pos.String() != "2:1" {
+		t.Errorf("first token position wrong. expected=2:1, got=%s", tok.Pos)
+	}
+
+	// without SkipShebang the line is ordinary input
+	l = New(input)
+
+	tok = l.NextToken()
+	if tok.Type != token.ILLEGAL || tok.Literal != "#" {
+		t.Fatalf("first token wrong. expected=ILLEGAL(#), got=%s(%q)", tok.Type, tok.Literal)
+	}
+}
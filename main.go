@@ -1,18 +1,122 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
+	"monkey/evaluator"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
 	"monkey/repl"
 	"os"
 	"os/user"
 )
 
+const usage = `Usage:
+  monkey                          start the REPL
+  monkey run FILE [ARGS...]       run a script
+  monkey FILE [ARGS...]           run a script (for #! lines)
+  monkey -e EXPR [ARGS...]        evaluate EXPR and print the result
+  monkey < FILE                   run a script read from stdin
+`
+
 func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run carries out the command line argv and returns the process exit status.
+// The REPL only starts when stdin is a terminal.
+func run(argv []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("monkey", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { fmt.Fprint(stderr, usage) }
+	expr := flags.String("e", "", "evaluate `expr`")
+	if err := flags.Parse(argv); err != nil {
+		return 2
+	}
+	args := flags.Args()
+
+	exprSet := false
+	flags.Visit(func(f *flag.Flag) { exprSet = exprSet || f.Name == "e" })
+	if exprSet {
+		return execute("<expr>", *expr, 0, args, stdout, stderr)
+	}
+
+	if len(args) > 0 {
+		if args[0] == "run" {
+			args = args[1:]
+			if len(args) == 0 {
+				fmt.Fprint(stderr, usage)
+				return 2
+			}
+		}
+		src, err := os.ReadFile(args[0])
+		if err != nil {
+			fmt.Fprintf(stderr, "monkey: %s\n", err)
+			return 1
+		}
+		return execute(args[0], string(src), lexer.SkipShebang, args[1:], nil, stderr)
+	}
+
+	if f, ok := stdin.(*os.File); !ok || !isTerminal(f) {
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "monkey: %s\n", err)
+			return 1
+		}
+		return execute("<stdin>", string(src), lexer.SkipShebang, nil, nil, stderr)
+	}
+
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
 	}
 
-	fmt.Printf("Hello %s!!\n", user.Username)
-	repl.Start(os.Stdin, os.Stdout)
-}
\ No newline at end of file
+	fmt.Fprintf(stdout, "Hello %s!!\n", user.Username)
+	repl.Start(stdin, stdout)
+	return 0
+}
+
+// execute runs src, lexed with mode, and returns the process exit status.
+// The script arguments are bound to the immutable array `args`. The result
+// is printed to stdout unless it is nil.
+func execute(filename string, src string, mode lexer.Mode, args []string, stdout io.Writer, stderr io.Writer) int {
+	l := lexer.NewWithMode(filename, src, mode)
+	p := parser.New(l)
+
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		for _, d := range p.Errors() {
+			io.WriteString(stderr, d.Render(src))
+		}
+		return 1
+	}
+
+	env := object.NewEnvironment()
+	elements := make([]object.Object, len(args))
+	for i, arg := range args {
+		elements[i] = &object.String{Value: arg}
+	}
+	env.Set("args", &object.Array{Elements: elements}, false)
+
+	evaluated := evaluator.Eval(program, env)
+	if err, ok := evaluated.(*object.Error); ok {
+		fmt.Fprintln(stderr, err.Traceback())
+		return 1
+	}
+
+	if stdout != nil && evaluated != nil && evaluated != evaluator.NULL {
+		fmt.Fprintln(stdout, evaluated.Inspect())
+	}
+
+	return 0
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "script.mk")
+	src := "#!/usr/bin/env monkey\nif (len(args) != 2) { 1 + true }\n"
+	if err := os.WriteFile(script, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.mk")
+
+	tests := []struct {
+		argv           []string
+		stdin          string
+		expectedStatus int
+		expectedStdout string
+		expectedStderr string // a substring of stderr, or "" for none at all
+	}{
+		{[]string{"-e", "1 + 2"}, "", 0, "3\n", ""},
+		{[]string{"-e", "args", "x", "y"}, "", 0, "[x, y]\n", ""},
+		{[]string{"-e", "let a = 1;"}, "", 0, "", ""},
+		{[]string{"-e", ""}, "", 0, "", ""},
+		{[]string{"-e", "#!x\n1"}, "", 1, "", `illegal character "#"`},
+		{[]string{"-e", "let x = ;"}, "", 1, "", "error[E0002]: no prefix parse function for ; found"},
+		{[]string{"-e", "1 + true"}, "", 1, "", "type mismatch: INTEGER + BOOLEAN"},
+		{[]string{script, "a", "b"}, "", 0, "", ""},
+		{[]string{"run", script, "a", "b"}, "", 0, "", ""},
+		{[]string{script, "a"}, "", 1, "", "at " + script + ":2:23, in <main>\nERROR: type mismatch"},
+		{[]string{"run"}, "", 2, "", "Usage:"},
+		{[]string{missing}, "", 1, "", "monkey: open " + missing},
+		{[]string{"-x"}, "", 2, "", "flag provided but not defined: -x"},
+		{nil, "#!/usr/bin/env monkey\nlet a = 1;\na + 1;", 0, "", ""},
+		{nil, "let a = 1;\na + true;", 1, "", "at <stdin>:2:1, in <main>\nERROR: type mismatch: INTEGER + BOOLEAN"},
+		{nil, "let = 1;", 1, "", "error[E0001]"},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		status := run(tt.argv, strings.NewReader(tt.stdin), &stdout, &stderr)
+
+		if status != tt.expectedStatus {
+			t.Errorf("%q: exit status is %d, want %d (stderr %q)", tt.argv, status, tt.expectedStatus, stderr.String())
+		}
+		if stdout.String() != tt.expectedStdout {
+			t.Errorf("%q: stdout is %q, want %q", tt.argv, stdout.String(), tt.expectedStdout)
+		}
+		if tt.expectedStderr == "" && stderr.Len() > 0 {
+			t.Errorf("%q: unexpected stderr %q", tt.argv, stderr.String())
+		}
+		if !strings.Contains(stderr.String(), tt.expectedStderr) {
+			t.Errorf("%q: stderr is %q, want it to contain %q", tt.argv, stderr.String(), tt.expectedStderr)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package object
 
+import "sort"
+
 type Value struct {
 	Obj Object
 	IsMutable bool
@@ -34,3 +36,26 @@ func (e *Environment) Set(name string, obj Object, isMutable bool) *Value {
 	e.store[name] = &val
 	return &val
 }
+
+// Assign rebinds name in the innermost environment that declares it, keeping
+// its mutability. It reports false when name is not bound.
+func (e *Environment) Assign(name string, obj Object) bool {
+	if val, ok := e.store[name]; ok {
+		val.Obj = obj
+		return true
+	}
+	if e.outer != nil {
+		return e.outer.Assign(name, obj)
+	}
+	return false
+}
+
+// Names returns the names bound in e itself, excluding outer environments, in sorted order.
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.store))
+	for name := range e.store {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,141 @@
+package object
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// Iterable is implemented by objects that a for-in loop can iterate over.
+type Iterable interface {
+	Object
+	Iterator() Iterator
+}
+
+// Iterator produces the elements of an Iterable one at a time.
+type Iterator interface {
+	// Next returns the key and the value of the next element, and false once
+	// there are no more elements. Sequences use the index as the key.
+	Next() (Object, Object, bool)
+}
+
+type arrayIterator struct {
+	elements []Object
+	index    int
+}
+
+func (ao *Array) Iterator() Iterator {
+	return &arrayIterator{elements: ao.Elements}
+}
+
+func (it *arrayIterator) Next() (Object, Object, bool) {
+	if it.index >= len(it.elements) {
+		return nil, nil, false
+	}
+	key := &Integer{Value: int64(it.index)}
+	value := it.elements[it.index]
+	it.index++
+	return key, value, true
+}
+
+// stringIterator yields the characters of a string as one-character strings.
+type stringIterator struct {
+	value  string
+	offset int
+	index  int
+}
+
+func (s *String) Iterator() Iterator {
+	return &stringIterator{value: s.Value}
+}
+
+func (it *stringIterator) Next() (Object, Object, bool) {
+	if it.offset >= len(it.value) {
+		return nil, nil, false
+	}
+	_, size := utf8.DecodeRuneInString(it.value[it.offset:])
+	key := &Integer{Value: int64(it.index)}
+	value := &String{Value: it.value[it.offset : it.offset+size]}
+	it.offset += size
+	it.index++
+	return key, value, true
+}
+
+type hashIterator struct {
+	pairs []HashPair
+	index int
+}
+
+// Iterator yields the pairs of the hash ordered by key, see SortedPairs.
+func (h *Hash) Iterator() Iterator {
+	return &hashIterator{pairs: h.SortedPairs()}
+}
+
+func (it *hashIterator) Next() (Object, Object, bool) {
+	if it.index >= len(it.pairs) {
+		return nil, nil, false
+	}
+	pair := it.pairs[it.index]
+	it.index++
+	return pair.Key, pair.Value, true
+}
+
+// SortedPairs returns the pairs of the hash ordered by key. Keys of the same
+// type are ordered by value, and keys of different types by type name.
+func (h *Hash) SortedPairs() []HashPair {
+	pairs := make([]HashPair, 0, len(h.Pairs))
+	for _, pair := range h.Pairs {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return keyLess(pairs[i].Key, pairs[j].Key)
+	})
+	return pairs
+}
+
+func keyLess(a Object, b Object) bool {
+	if a.Type() != b.Type() {
+		return a.Type() < b.Type()
+	}
+
+	switch a := a.(type) {
+	case *Integer:
+		if b, ok := b.(*Integer); ok {
+			return a.Value < b.Value
+		}
+		return b.(*BigInt).Value.Sign() > 0
+	case *BigInt:
+		if b, ok := b.(*BigInt); ok {
+			return a.Value.Cmp(b.Value) < 0
+		}
+		return a.Value.Sign() < 0
+	case *Float:
+		return a.Value < b.(*Float).Value
+	case *String:
+		return a.Value < b.(*String).Value
+	case *Boolean:
+		return !a.Value && b.(*Boolean).Value
+	default:
+		return a.Inspect() < b.Inspect()
+	}
+}
+
+type rangeIterator struct {
+	next  int64
+	end   int64
+	index int64
+}
+
+func (r *Range) Iterator() Iterator {
+	return &rangeIterator{next: r.Start, end: r.End}
+}
+
+func (it *rangeIterator) Next() (Object, Object, bool) {
+	if it.next >= it.end {
+		return nil, nil, false
+	}
+	key := &Integer{Value: it.index}
+	value := &Integer{Value: it.next}
+	it.next++
+	it.index++
+	return key, value, true
+}
@@ -4,7 +4,11 @@ import (
 	"bytes"
 	"fmt"
 	"hash/fnv"
+	"math"
+	"math/big"
 	"monkey/ast"
+	"monkey/token"
+	"strconv"
 	"strings"
 )
 
@@ -12,15 +16,19 @@ type ObjectType string
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
 	STRING_OBJ       = "STRING"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
 	ERROR_OBJ        = "ERROR"
 	FUNCTION_OBJ     = "FUNCTION"
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
+	RANGE_OBJ        = "RANGE"
 )
 
 type Object interface {
@@ -39,6 +47,36 @@ func (i *Integer) Type() ObjectType {
 	return INTEGER_OBJ
 }
 
+// BigInt is an integer outside the int64 range. It has the same type as
+// Integer; results are normalized so that values fitting int64 are Integers.
+type BigInt struct {
+	Value *big.Int
+}
+
+func (b *BigInt) Inspect() string {
+	return b.Value.String()
+}
+func (b *BigInt) Type() ObjectType {
+	return INTEGER_OBJ
+}
+
+type Float struct {
+	Value float64
+}
+
+// Inspect formats the shortest representation that reads back as the same
+// value, always marked as a float, e.g. "2.0" rather than "2".
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+	return s
+}
+func (f *Float) Type() ObjectType {
+	return FLOAT_OBJ
+}
+
 type String struct {
 	Value string
 }
@@ -81,16 +119,64 @@ func (rv *ReturnValue) Inspect() string {
 	return rv.Value.Inspect()
 }
 
-// TODO Line Number
+// Break signals a break statement to the innermost enclosing loop.
+type Break struct {
+}
+
+func (b *Break) Type() ObjectType { return BREAK_OBJ }
+func (b *Break) Inspect() string {
+	return "break"
+}
+
+// Continue signals a continue statement to the innermost enclosing loop.
+type Continue struct {
+}
+
+func (c *Continue) Type() ObjectType { return CONTINUE_OBJ }
+func (c *Continue) Inspect() string {
+	return "continue"
+}
+
 type Error struct {
 	Message string
+	Pos     token.Position // span of the expression that failed
+	End     token.Position
+	Stack   []Frame // calls that were active when the error occurred, innermost first
+}
+
+// Frame is a function call that an error unwound through.
+type Frame struct {
+	Function string         // function name, or "<anonymous>"
+	Pos      token.Position // call site
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
 func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
 
+// Traceback formats the error with the call stack, most recent call last.
+func (e *Error) Traceback() string {
+	var out bytes.Buffer
+
+	if e.Pos.IsValid() || len(e.Stack) > 0 {
+		out.WriteString("Traceback (most recent call last):\n")
+
+		caller := "<main>"
+		for i := len(e.Stack) - 1; i >= 0; i-- {
+			out.WriteString(fmt.Sprintf("  at %s, in %s\n", e.Stack[i].Pos, caller))
+			caller = e.Stack[i].Function
+		}
+		out.WriteString(fmt.Sprintf("  at %s, in %s\n", e.Pos, caller))
+	}
+	out.WriteString(e.Inspect())
+
+	return out.String()
+}
+
 type Function struct {
+	Name       string
 	Parameters []*ast.Identifier
+	Defaults   []ast.Expression // default value of each parameter, nil for required ones
+	Rest       *ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
 }
@@ -100,8 +186,15 @@ func (f *Function) Inspect() string {
 	var out bytes.Buffer
 
 	params := []string{}
-	for _, p := range f.Parameters {
-		params = append(params, p.String())
+	for i, p := range f.Parameters {
+		if i < len(f.Defaults) && f.Defaults[i] != nil {
+			params = append(params, p.String()+" = "+f.Defaults[i].String())
+		} else {
+			params = append(params, p.String())
+		}
+	}
+	if f.Rest != nil {
+		params = append(params, "..."+f.Rest.String())
 	}
 
 	out.WriteString("fn")
@@ -117,6 +210,10 @@ func (f *Function) Inspect() string {
 type BuiltinFunction func(args ...Object) Object
 type Builtin struct {
 	Fn BuiltinFunction
+	// MinArgs and MaxArgs bound the number of arguments Fn is called with.
+	// A negative MaxArgs means there is no upper bound.
+	MinArgs int
+	MaxArgs int
 }
 
 func (b *Builtin) Type() ObjectType {
@@ -149,6 +246,17 @@ func (ao *Array) Inspect() string {
 	return out.String()
 }
 
+// Range is the half-open interval of integers [Start, End).
+type Range struct {
+	Start int64
+	End   int64
+}
+
+func (r *Range) Type() ObjectType { return RANGE_OBJ }
+func (r *Range) Inspect() string {
+	return fmt.Sprintf("%d..%d", r.Start, r.End)
+}
+
 type HashKey struct {
 	Type  ObjectType
 	Value uint64
@@ -173,6 +281,27 @@ func (i *Integer) HashKey() HashKey {
 	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
 }
 
+func (b *BigInt) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte{byte(b.Value.Sign() + 1)})
+	h.Write(b.Value.Bytes())
+	return HashKey{Type: b.Type(), Value: h.Sum64()}
+}
+
+// HashKey returns the key of the equal integer for an integral float, since
+// 1 == 1.0 must find the same hash entry.
+func (f *Float) HashKey() HashKey {
+	value := f.Value
+	if value == math.Trunc(value) && !math.IsInf(value, 0) {
+		if value >= math.MinInt64 && value < math.MaxInt64 {
+			return (&Integer{Value: int64(value)}).HashKey()
+		}
+		bigValue, _ := big.NewFloat(value).Int(nil)
+		return (&BigInt{Value: bigValue}).HashKey()
+	}
+	return HashKey{Type: f.Type(), Value: math.Float64bits(value)}
+}
+
 func (s *String) HashKey() HashKey {
 	h := fnv.New64a()
 	h.Write([]byte(s.Value))
@@ -1,6 +1,10 @@
 package object
 
-import "testing"
+import (
+	"math"
+	"math/big"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -20,3 +24,52 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("strings with differenet content have same hash keys.")
 	}
 }
+
+func TestHashIterator(t *testing.T) {
+	hash := &Hash{Pairs: map[HashKey]HashPair{}}
+	for _, key := range []Object{
+		&String{Value: "b"},
+		&Integer{Value: 2},
+		&String{Value: "a"},
+		&Boolean{Value: true},
+		&Integer{Value: -1},
+		&Boolean{Value: false},
+	} {
+		hash.Pairs[key.(Hashable).HashKey()] = HashPair{Key: key, Value: &Null{}}
+	}
+
+	expected := []string{"false", "true", "-1", "2", "a", "b"}
+
+	it := hash.Iterator()
+	for i, want := range expected {
+		key, _, ok := it.Next()
+		if !ok {
+			t.Fatalf("iterator ended after %d keys, want %d", i, len(expected))
+		}
+		if key.Inspect() != want {
+			t.Errorf("key %d is %s, want %s", i, key.Inspect(), want)
+		}
+	}
+	if _, _, ok := it.Next(); ok {
+		t.Errorf("iterator did not end after %d keys", len(expected))
+	}
+}
+
+func TestFloatHashKey(t *testing.T) {
+	if (&Float{Value: 1.5}).HashKey() != (&Float{Value: 1.5}).HashKey() {
+		t.Errorf("floats with same value have different hash keys.")
+	}
+	if (&Float{Value: 0}).HashKey() != (&Float{Value: math.Copysign(0, -1)}).HashKey() {
+		t.Errorf("zero and negative zero have different hash keys.")
+	}
+	if (&Float{Value: -3}).HashKey() != (&Integer{Value: -3}).HashKey() {
+		t.Errorf("integral float and equal integer have different hash keys.")
+	}
+	big := new(big.Int).Lsh(big.NewInt(1), 64)
+	if (&Float{Value: math.Pow(2, 64)}).HashKey() != (&BigInt{Value: big}).HashKey() {
+		t.Errorf("integral float and equal big integer have different hash keys.")
+	}
+	if (&Float{Value: 1.5}).HashKey() == (&Float{Value: 2.5}).HashKey() {
+		t.Errorf("floats with different values have same hash keys.")
+	}
+}
@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"monkey/token"
+	"strings"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Diagnostic codes, stable across releases so that tools can match on them.
+const (
+	CodeUnexpectedToken     = "E0001"
+	CodeNoPrefixParseFn     = "E0002"
+	CodeInvalidInteger      = "E0003"
+	CodeUnclosedBlock       = "E0004"
+	CodeInvalidAssignTarget = "E0005"
+	CodeIllegalCharacter    = "E0006"
+	CodeInvalidFloat        = "E0007"
+	CodeInvalidParameter    = "E0008"
+	CodeInvalidArgument     = "E0009"
+	CodeOutsideLoop         = "E0010"
+	CodeUnterminatedComment = "E0011"
+	CodeUnterminatedString  = "E0012"
+	CodeInvalidEscape       = "E0013"
+	CodeInvalidUTF8         = "E0014"
+	CodeInvalidNumber       = "E0015"
+)
+
+// Diagnostic is a problem found in the source, located by the span [Pos, End).
+type Diagnostic struct {
+	Severity Severity       `json:"severity"`
+	Code     string         `json:"code"`
+	Message  string         `json:"message"`
+	Pos      token.Position `json:"pos"`
+	End      token.Position `json:"end"`
+	Hints    []string       `json:"hints,omitempty"`
+}
+
+// String formats the diagnostic on a single line, e.g.
+// "script.mk:1:9: error[E0002]: no prefix parse function for ) found".
+func (d *Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s[%s]: %s", d.Pos, d.Severity, d.Code, d.Message)
+}
+
+func (d *Diagnostic) Error() string {
+	return d.String()
+}
+
+// Render formats the diagnostic together with the offending line of src,
+// underlining the span with ^~~~.
+func (d *Diagnostic) Render(src string) string {
+	var out bytes.Buffer
+
+	out.WriteString(fmt.Sprintf("%s[%s]: %s\n", d.Severity, d.Code, d.Message))
+
+	line, ok := sourceLine(src, d.Pos.Line)
+	if !d.Pos.IsValid() || !ok {
+		out.WriteString(fmt.Sprintf(" --> %s\n", d.Pos))
+		for _, hint := range d.Hints {
+			out.WriteString(fmt.Sprintf(" = hint: %s\n", hint))
+		}
+		return out.String()
+	}
+
+	lineNo := fmt.Sprintf("%d", d.Pos.Line)
+	gutter := strings.Repeat(" ", len(lineNo))
+
+	out.WriteString(fmt.Sprintf("%s--> %s\n", gutter, d.Pos))
+	out.WriteString(fmt.Sprintf("%s |\n", gutter))
+	out.WriteString(fmt.Sprintf("%s | %s\n", lineNo, line))
+	out.WriteString(fmt.Sprintf("%s | %s\n", gutter, underline(line, d.Pos, d.End)))
+	for _, hint := range d.Hints {
+		out.WriteString(fmt.Sprintf("%s = hint: %s\n", gutter, hint))
+	}
+
+	return out.String()
+}
+
+// sourceLine returns the n-th line of src, counting from 1.
+func sourceLine(src string, n int) (string, bool) {
+	lines := strings.Split(src, "\n")
+	if n < 1 || n > len(lines) {
+		return "", false
+	}
+	return strings.TrimRight(lines[n-1], "\r"), true
+}
+
+// underline returns the marker line for the span [pos, end) within line.
+// Spans reaching past the line are cut off at its end. Columns count
+// characters, and wide characters take two cells in the terminal.
+func underline(line string, pos token.Position, end token.Position) string {
+	chars := []rune(line)
+
+	start := pos.Column - 1
+	if start > len(chars) {
+		start = len(chars)
+	}
+
+	stop := start + 1
+	if end.Line == pos.Line && end.Column > pos.Column {
+		stop = end.Column - 1
+	} else if end.Line > pos.Line {
+		stop = len(chars)
+	}
+	if stop > len(chars) {
+		stop = len(chars)
+	}
+
+	width := 0
+	for _, ch := range chars[start:stop] {
+		width += cellWidth(ch)
+	}
+	if width < 1 {
+		width = 1
+	}
+
+	var out bytes.Buffer
+	// keep tabs so that the marker lines up with the source
+	for _, ch := range chars[:start] {
+		if ch == '\t' {
+			out.WriteByte('\t')
+		} else {
+			out.WriteString(strings.Repeat(" ", cellWidth(ch)))
+		}
+	}
+	out.WriteString("^")
+	out.WriteString(strings.Repeat("~", width-1))
+
+	return out.String()
+}
+
+// cellWidth returns the number of terminal cells ch takes: two for the wide
+// characters of East Asian scripts, one for everything else.
+func cellWidth(ch rune) int {
+	switch {
+	case 0x1100 <= ch && ch <= 0x115F, // Hangul Jamo
+		0x2E80 <= ch && ch <= 0x303E, // CJK radicals and punctuation
+		0x3041 <= ch && ch <= 0x33FF, // kana and CJK compatibility
+		0x3400 <= ch && ch <= 0x4DBF, // CJK extension A
+		0x4E00 <= ch && ch <= 0x9FFF, // CJK unified ideographs
+		0xA000 <= ch && ch <= 0xA4CF, // Yi
+		0xAC00 <= ch && ch <= 0xD7A3, // Hangul syllables
+		0xF900 <= ch && ch <= 0xFAFF, // CJK compatibility ideographs
+		0xFE30 <= ch && ch <= 0xFE4F, // CJK compatibility forms
+		0xFF00 <= ch && ch <= 0xFF60, // fullwidth forms
+		0xFFE0 <= ch && ch <= 0xFFE6,
+		0x20000 <= ch && ch <= 0x3FFFD: // CJK extensions B and later
+		return 2
+	default:
+		return 1
+	}
+}
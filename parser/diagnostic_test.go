@@ -0,0 +1,144 @@
+package parser
+
+import (
+	"encoding/json"
+	"monkey/lexer"
+	"monkey/token"
+	"strings"
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedCode    string
+		expectedMessage string
+		expectedPos     string
+		expectedEnd     string
+		expectedHints   []string
+	}{
+		{"let x = );", CodeNoPrefixParseFn, "no prefix parse function for ) found", "1:9", "1:10", nil},
+		{"let x == 5;", CodeUnexpectedToken, "expected next token to be =, got == instead", "1:7", "1:9", []string{"did you mean `=`?"}},
+		{"x == = 5;", CodeNoPrefixParseFn, "no prefix parse function for = found", "1:6", "1:7", []string{"did you mean `==`?"}},
+		{"09", CodeInvalidNumber, "invalid digit '9' in octal literal", "1:2", "1:3", nil},
+		{"let m = 0x;", CodeInvalidNumber, "hexadecimal literal has no digits", "1:9", "1:11", nil},
+		{"1__0", CodeInvalidNumber, "'_' must separate successive digits", "1:2", "1:3", nil},
+		{"0b1012", CodeInvalidNumber, "invalid digit '2' in binary literal", "1:6", "1:7", nil},
+		{"fn(x) {\n  x", CodeUnclosedBlock, "expected next token to be }, got EOF instead", "2:4", "2:4", []string{"the block was opened at 1:7"}},
+		{"foo(1) = 2", CodeInvalidAssignTarget, "cannot assign to foo(1)", "1:1", "1:7", nil},
+		{"foo()[0] += 2", CodeInvalidAssignTarget, "cannot assign to (foo()[0])", "1:1", "1:9", nil},
+		{"a[1:2] = 3", CodeInvalidAssignTarget, "cannot assign to (a[1:2])", "1:1", "1:7", nil},
+		{"1 + @", CodeIllegalCharacter, `illegal character "@"`, "1:5", "1:6", nil},
+		{"let @ = 1", CodeIllegalCharacter, `illegal character "@"`, "1:5", "1:6", nil},
+		{"puts(\"a);", CodeUnterminatedString, "unterminated string literal", "1:6", "1:10", nil},
+		{`let s = "a\zb";`, CodeInvalidEscape, `unknown escape sequence \z`, "1:11", "1:13", nil},
+		{"fn(...a, b) {}", CodeInvalidParameter, "rest parameter a must be last", "1:7", "1:8", nil},
+		{"fn(a = 1, b) {}", CodeInvalidParameter, "parameter b without a default follows a parameter with a default", "1:11", "1:12", nil},
+		{"f(a: 1, 2)", CodeInvalidArgument, "positional argument follows named arguments", "1:9", "1:10", nil},
+		{"if (x) { continue }", CodeOutsideLoop, "continue outside of a loop", "1:10", "1:18", nil},
+		{"x; /* a /* b */\ny", CodeUnterminatedComment, "unterminated block comment", "1:4", "2:2", nil},
+		{"let 名前 = \xff;", CodeInvalidUTF8, "invalid UTF-8 encoding", "1:10", "1:11", nil},
+		{`"日本" + @`, CodeIllegalCharacter, `illegal character "@"`, "1:8", "1:9", nil},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		if len(p.Errors()) != 1 {
+			t.Errorf("%q: expected 1 diagnostic, got %d (%q)", tt.input, len(p.Errors()), p.Errors())
+			continue
+		}
+
+		d := p.Errors()[0]
+		if d.Severity != Error {
+			t.Errorf("%q: severity is %s, want %s", tt.input, d.Severity, Error)
+		}
+		if d.Code != tt.expectedCode {
+			t.Errorf("%q: code is %s, want %s", tt.input, d.Code, tt.expectedCode)
+		}
+		if d.Message != tt.expectedMessage {
+			t.Errorf("%q: message is %q, want %q", tt.input, d.Message, tt.expectedMessage)
+		}
+		if d.Pos.String() != tt.expectedPos || d.End.String() != tt.expectedEnd {
+			t.Errorf("%q: span is %s-%s, want %s-%s", tt.input, d.Pos, d.End, tt.expectedPos, tt.expectedEnd)
+		}
+		if len(d.Hints) != len(tt.expectedHints) {
+			t.Errorf("%q: hints are %q, want %q", tt.input, d.Hints, tt.expectedHints)
+			continue
+		}
+		for i, hint := range tt.expectedHints {
+			if d.Hints[i] != hint {
+				t.Errorf("%q: hints[%d] is %q, want %q", tt.input, i, d.Hints[i], hint)
+			}
+		}
+	}
+}
+
+func TestDiagnosticRender(t *testing.T) {
+	input := "let a = 1;\n\tlet b == a + 1;"
+
+	p := New(lexer.NewWithFilename("script.mk", input))
+	p.ParseProgram()
+
+	if len(p.Errors()) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %d (%q)", len(p.Errors()), p.Errors())
+	}
+
+	expected := "error[E0001]: expected next token to be =, got == instead\n" +
+		" --> script.mk:2:8\n" +
+		"  |\n" +
+		"2 | \tlet b == a + 1;\n" +
+		"  | \t      ^~\n" +
+		"  = hint: did you mean `=`?\n"
+
+	if actual := p.Errors()[0].Render(input); actual != expected {
+		t.Errorf("Render() returns\n%s\nwant\n%s", actual, expected)
+	}
+
+	if actual := p.Errors()[0].String(); actual != "script.mk:2:8: error[E0001]: expected next token to be =, got == instead" {
+		t.Errorf("String() returns %q", actual)
+	}
+}
+
+func TestDiagnosticRenderWideCharacters(t *testing.T) {
+	input := `let 名前 = "日本語" +* 1;`
+
+	p := New(lexer.New(input))
+	p.ParseProgram()
+
+	if len(p.Errors()) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %d (%q)", len(p.Errors()), p.Errors())
+	}
+
+	expected := "error[E0002]: no prefix parse function for * found\n" +
+		" --> 1:17\n" +
+		"  |\n" +
+		"1 | let 名前 = \"日本語\" +* 1;\n" +
+		"  | " + strings.Repeat(" ", 21) + "^\n"
+
+	if actual := p.Errors()[0].Render(input); actual != expected {
+		t.Errorf("Render() returns\n%s\nwant\n%s", actual, expected)
+	}
+
+	pos, end := token.Position{Line: 1, Column: 5}, token.Position{Line: 1, Column: 7}
+	if actual := underline("let 名前 = 1", pos, end); actual != "    ^~~~" {
+		t.Errorf("underline() returns %q", actual)
+	}
+}
+
+func TestDiagnosticJSON(t *testing.T) {
+	p := New(lexer.New("let x = );"))
+	p.ParseProgram()
+
+	b, err := json.Marshal(p.Errors())
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %s", err)
+	}
+
+	expected := `[{"severity":"error","code":"E0002","message":"no prefix parse function for ) found",` +
+		`"pos":{"offset":8,"line":1,"column":9},"end":{"offset":9,"line":1,"column":10}}]`
+	if string(b) != expected {
+		t.Errorf("json.Marshal returns %s, want %s", b, expected)
+	}
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"math/big"
 	"monkey/ast"
 	"monkey/lexer"
 	"monkey/token"
@@ -11,7 +12,18 @@ import (
 type Parser struct {
 	l *lexer.Lexer
 
-	errors []string
+	errors []*Diagnostic
+	// lexerErrors is the number of lexer errors already added to errors.
+	lexerErrors int
+
+	// panicking is set after a syntax error is reported; further errors are
+	// suppressed until the parser synchronizes on the next statement.
+	panicking bool
+	// depth is the number of braces opened up to and including curToken.
+	depth int
+	// loopDepth is the number of loops enclosing curToken within the current
+	// function body; break and continue are only allowed when it is positive.
+	loopDepth int
 
 	curToken  token.Token
 	peekToken token.Token
@@ -29,11 +41,19 @@ const (
 	_ int = iota
 	LOWEST
 	ASSIGN
+	RANGE
+	OR
+	AND
 	EQUALS
 	LESSGREATER
+	BIT_OR
+	BIT_XOR
+	BIT_AND
+	SHIFT
 	SUM
 	PRODUCT
 	PREFIX
+	POWER
 	CALL
 	INDEX
 )
@@ -43,17 +63,35 @@ var precedences = map[token.TokenType]int{
 	token.NOT_EQ:   EQUALS,
 	token.LT:       LESSGREATER,
 	token.GT:       LESSGREATER,
+	token.LT_EQ:    LESSGREATER,
+	token.GT_EQ:    LESSGREATER,
+	token.AND:      AND,
+	token.OR:       OR,
 	token.PLUS:     SUM,
 	token.MINUS:    SUM,
 	token.SLASH:    PRODUCT,
 	token.ASTERISK: PRODUCT,
+	token.PERCENT:  PRODUCT,
+	token.POW:      POWER,
+	token.BIT_OR:   BIT_OR,
+	token.BIT_XOR:  BIT_XOR,
+	token.BIT_AND:  BIT_AND,
+	token.SHL:      SHIFT,
+	token.SHR:      SHIFT,
 	token.LPAREN:   CALL,
 	token.LBRACKET: INDEX,
 	token.ASSIGN:   ASSIGN,
+	token.RANGE:    RANGE,
+
+	token.PLUS_ASSIGN:     ASSIGN,
+	token.MINUS_ASSIGN:    ASSIGN,
+	token.ASTERISK_ASSIGN: ASSIGN,
+	token.SLASH_ASSIGN:    ASSIGN,
+	token.PERCENT_ASSIGN:  ASSIGN,
 }
 
 func New(l *lexer.Lexer) *Parser {
-	p := &Parser{l: l, errors: []string{},}
+	p := &Parser{l: l, errors: []*Diagnostic{}}
 
 	// initialize curToken and peekToken
 	p.nextToken()
@@ -62,9 +100,12 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParsefns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.INTERP_BEGIN, p.parseInterpolatedString)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(token.BIT_NOT, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
@@ -72,19 +113,37 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
+	p.registerPrefix(token.ILLEGAL, p.parseIllegal)
 
 	p.infixParsefns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
+	p.registerInfix(token.POW, p.parseInfixExpression)
+	p.registerInfix(token.BIT_AND, p.parseInfixExpression)
+	p.registerInfix(token.BIT_OR, p.parseInfixExpression)
+	p.registerInfix(token.BIT_XOR, p.parseInfixExpression)
+	p.registerInfix(token.SHL, p.parseInfixExpression)
+	p.registerInfix(token.SHR, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.GT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
 	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.ASTERISK_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.SLASH_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PERCENT_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.RANGE, p.parseRangeExpression)
 
 	return p
 }
@@ -92,6 +151,17 @@ func New(l *lexer.Lexer) *Parser {
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.l.NextToken()
+	for p.peekToken.Type == token.COMMENT {
+		p.peekToken = p.l.NextToken()
+	}
+	p.reportLexerErrors()
+
+	switch p.curToken.Type {
+	case token.LBRACE:
+		p.depth++
+	case token.RBRACE:
+		p.depth--
+	}
 }
 
 func (p *Parser) ParseProgram() *ast.Program {
@@ -110,6 +180,36 @@ func (p *Parser) ParseProgram() *ast.Program {
 }
 
 func (p *Parser) parseStatement() ast.Statement {
+	depth := p.depth
+
+	stmt := p.parseStatementWithoutRecovery()
+
+	if p.panicking {
+		p.synchronize(depth)
+		p.panicking = false
+	}
+
+	return stmt
+}
+
+// synchronize skips the rest of a statement that started at the given brace
+// depth, so that parsing can resume at the start of the next one.
+func (p *Parser) synchronize(depth int) {
+	for !p.curTokenIs(token.EOF) && p.depth >= depth {
+		if p.depth == depth {
+			if p.curTokenIs(token.SEMICOLON) {
+				return
+			}
+			switch p.peekToken.Type {
+			case token.LET, token.VAR, token.RETURN, token.FOR, token.WHILE, token.BREAK, token.CONTINUE, token.RBRACE, token.EOF:
+				return
+			}
+		}
+		p.nextToken()
+	}
+}
+
+func (p *Parser) parseStatementWithoutRecovery() ast.Statement {
 	switch p.curToken.Type {
 	case token.LET:
 		return p.parseLetStatement()
@@ -119,27 +219,36 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseReturnStatement()
 	case token.FOR:
 		return p.parseForStatement()
+	case token.WHILE:
+		return p.parseWhileStatement()
+	case token.BREAK:
+		return p.parseBreakStatement()
+	case token.CONTINUE:
+		return p.parseContinueStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
 }
 
-func (p *Parser) parseLetStatement() *ast.LetStatement {
+func (p *Parser) parseLetStatement() ast.Statement {
 	stmt := &ast.LetStatement{Token: p.curToken}
 
 	if !p.expectPeek(token.IDENT) {
-		return nil
+		return p.badStatement(stmt.Token)
 	}
 
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
 	if !p.expectPeek(token.ASSIGN) {
-		return nil
+		return p.badStatement(stmt.Token)
 	}
 
 	p.nextToken()
 
 	stmt.Value = p.parseExpression(LOWEST)
+	if fl, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		fl.Name = stmt.Name.Value
+	}
 
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
@@ -148,22 +257,25 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	return stmt
 }
 
-func (p *Parser) parseVarStatement() *ast.VarStatement {
+func (p *Parser) parseVarStatement() ast.Statement {
 	stmt := &ast.VarStatement{Token: p.curToken}
 
 	if !p.expectPeek(token.IDENT) {
-		return nil
+		return p.badStatement(stmt.Token)
 	}
 
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
 	if !p.expectPeek(token.ASSIGN) {
-		return nil
+		return p.badStatement(stmt.Token)
 	}
 
 	p.nextToken()
 
 	stmt.Value = p.parseExpression(LOWEST)
+	if fl, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		fl.Name = stmt.Name.Value
+	}
 
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
@@ -172,52 +284,174 @@ func (p *Parser) parseVarStatement() *ast.VarStatement {
 	return stmt
 }
 
-func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
+func (p *Parser) parseReturnStatement() ast.Statement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
 
 	p.nextToken()
 
 	stmt.ReturnValue = p.parseExpression(LOWEST)
 
-	for !p.curTokenIs(token.SEMICOLON) {
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
 	return stmt
 }
 
-func (p *Parser) parseForStatement() *ast.ForStatement {
+func (p *Parser) parseForStatement() ast.Statement {
 	stmt := &ast.ForStatement{Token: p.curToken}
 
 	if !p.expectPeek(token.LPAREN) {
-		return nil
+		return p.badStatement(stmt.Token)
 	}
 	p.nextToken()
 
-	stmt.InitialStatement = p.parseStatement()
+	if p.curTokenIs(token.IDENT) && (p.peekTokenIs(token.IN) || p.peekTokenIs(token.COMMA)) {
+		return p.parseForInStatement(stmt.Token)
+	}
+
+	if !p.curTokenIs(token.SEMICOLON) {
+		stmt.InitialStatement = p.parseStatement()
+		if !p.curTokenIs(token.SEMICOLON) && !p.expectPeek(token.SEMICOLON) {
+			return p.badStatement(stmt.Token)
+		}
+	}
+	p.nextToken()
 
+	if !p.curTokenIs(token.SEMICOLON) {
+		stmt.Condition = p.parseExpression(LOWEST)
+		if !p.expectPeek(token.SEMICOLON) {
+			return p.badStatement(stmt.Token)
+		}
+	}
 	p.nextToken()
 
-	stmt.Condition = p.parseExpression(LOWEST)
-	if !p.expectPeek(token.SEMICOLON) {
-		return nil
+	if !p.curTokenIs(token.RPAREN) {
+		stmt.PostStatement = p.parseStatement()
+		if !p.expectPeek(token.RPAREN) {
+			return p.badStatement(stmt.Token)
+		}
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return p.badStatement(stmt.Token)
+	}
+
+	stmt.Block = p.parseLoopBody()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseForInStatement parses the rest of "for (value in iterable) { }" or
+// "for (key, value in iterable) { }", starting at the first identifier.
+func (p *Parser) parseForInStatement(forToken token.Token) ast.Statement {
+	stmt := &ast.ForInStatement{Token: forToken}
+
+	stmt.Value = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return p.badStatement(stmt.Token)
+		}
+		stmt.Key = stmt.Value
+		stmt.Value = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	if !p.expectPeek(token.IN) {
+		return p.badStatement(stmt.Token)
 	}
 	p.nextToken()
 
-	stmt.PostStatement = p.parseStatement()
+	stmt.Iterable = p.parseExpression(LOWEST)
 
-	if p.peekTokenIs(token.RPAREN) {
+	if !p.expectPeek(token.RPAREN) {
+		return p.badStatement(stmt.Token)
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return p.badStatement(stmt.Token)
+	}
+
+	stmt.Block = p.parseLoopBody()
+
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
+
+	return stmt
+}
+
+func (p *Parser) parseWhileStatement() ast.Statement {
+	stmt := &ast.WhileStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return p.badStatement(stmt.Token)
+	}
 	p.nextToken()
 
-	stmt.Block = p.parseBlockStatement()
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return p.badStatement(stmt.Token)
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return p.badStatement(stmt.Token)
+	}
+
+	stmt.Block = p.parseLoopBody()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseLoopBody parses the block of a loop, in which break and continue are allowed.
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	p.loopDepth++
+	block := p.parseBlockStatement()
+	p.loopDepth--
+
+	return block
+}
+
+func (p *Parser) parseBreakStatement() ast.Statement {
+	stmt := &ast.BreakStatement{Token: p.curToken}
+
+	if p.loopDepth == 0 {
+		p.errorf(CodeOutsideLoop, stmt.Token.Pos, stmt.Token.End, "break outside of a loop")
+		return p.badStatement(stmt.Token)
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
 
 	return stmt
 }
 
+func (p *Parser) parseContinueStatement() ast.Statement {
+	stmt := &ast.ContinueStatement{Token: p.curToken}
+
+	if p.loopDepth == 0 {
+		p.errorf(CodeOutsideLoop, stmt.Token.Pos, stmt.Token.End, "continue outside of a loop")
+		return p.badStatement(stmt.Token)
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
 
-func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
+	return stmt
+}
+
+func (p *Parser) parseExpressionStatement() ast.Statement {
 	stmt := &ast.ExpressionStatement{Token: p.curToken}
 
 	stmt.Expression = p.parseExpression(LOWEST)
@@ -230,15 +464,17 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 }
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	d := p.errorf(CodeNoPrefixParseFn, p.curToken.Pos, p.curToken.End, "no prefix parse function for %s found", t)
+	if t == token.ASSIGN {
+		d.Hints = append(d.Hints, "did you mean `==`?")
+	}
 }
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
 	prefix := p.prefixParsefns[p.curToken.Type]
 	if prefix == nil {
 		p.noPrefixParseFnError(p.curToken.Type)
-		return nil
+		return p.badExpression(p.curToken)
 	}
 	leftExp := prefix()
 
@@ -263,20 +499,68 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	if err == nil {
+		lit.Value = value
+		return lit
+	}
+
+	if bigValue, ok := new(big.Int).SetString(p.curToken.Literal, 0); ok {
+		lit.Big = bigValue
+		return lit
+	}
+
+	p.errorf(CodeInvalidInteger, lit.Token.Pos, lit.Token.End, "could not parse %q as integer", lit.Token.Literal)
+	return p.badExpression(lit.Token)
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
-		return nil
+		p.errorf(CodeInvalidFloat, lit.Token.Pos, lit.Token.End, "could not parse %q as float", lit.Token.Literal)
+		return p.badExpression(lit.Token)
 	}
 
 	lit.Value = value
 	return lit
 }
 
+// parseIllegal returns a placeholder for an ILLEGAL token. The lexer has
+// reported the malformed input already, so this only starts error recovery.
+func (p *Parser) parseIllegal() ast.Expression {
+	p.panicking = true
+	return p.badExpression(p.curToken)
+}
+
 func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	str := &ast.InterpolatedString{Token: p.curToken}
+	str.Parts = append(str.Parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+
+	for {
+		p.nextToken()
+		str.Parts = append(str.Parts, p.parseExpression(LOWEST))
+
+		if !p.peekTokenIs(token.INTERP_MIDDLE) {
+			break
+		}
+		p.nextToken()
+		str.Parts = append(str.Parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+	}
+
+	if !p.expectPeek(token.INTERP_END) {
+		return p.badExpression(str.Token)
+	}
+	str.EndToken = p.curToken
+	str.Parts = append(str.Parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+
+	return str
+}
+
 func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
 }
@@ -301,6 +585,10 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	}
 
 	precedences := p.curPrecedence()
+	if exp.Token.Type == token.POW {
+		// right-associative: 2 ** 3 ** 2 is 2 ** (3 ** 2)
+		precedences--
+	}
 
 	p.nextToken()
 	exp.Right = p.parseExpression(precedences)
@@ -308,22 +596,32 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+func (p *Parser) parseRangeExpression(from ast.Expression) ast.Expression {
+	exp := &ast.RangeExpression{Token: p.curToken, From: from}
+
+	precedence := p.curPrecedence()
+	p.nextToken()
+	exp.To = p.parseExpression(precedence)
+
+	return exp
+}
+
 func (p *Parser) parseIfExpression() ast.Expression {
 	exp := &ast.IfExpression{Token: p.curToken}
 
 	if !p.expectPeek(token.LPAREN) {
-		return nil
+		return p.badExpression(exp.Token)
 	}
 
 	p.nextToken()
 	exp.Condition = p.parseExpression(LOWEST)
 
 	if !p.expectPeek(token.RPAREN) {
-		return nil
+		return p.badExpression(exp.Token)
 	}
 
 	if !p.expectPeek(token.LBRACE) {
-		return nil
+		return p.badExpression(exp.Token)
 	}
 
 	exp.Consequence = p.parseBlockStatement()
@@ -332,7 +630,7 @@ func (p *Parser) parseIfExpression() ast.Expression {
 		p.nextToken()
 
 		if !p.expectPeek(token.LBRACE) {
-			return nil
+			return p.badExpression(exp.Token)
 		}
 
 		exp.Alternative = p.parseBlockStatement()
@@ -344,6 +642,7 @@ func (p *Parser) parseIfExpression() ast.Expression {
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	b := &ast.BlockStatement{Token: p.curToken}
 	b.Statements = []ast.Statement{}
+	depth := p.depth
 
 	p.nextToken()
 	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
@@ -351,19 +650,31 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		if stmt != nil {
 			b.Statements = append(b.Statements, stmt)
 		}
+		if p.depth < depth {
+			// a malformed statement ran into the brace closing this block
+			break
+		}
 		p.nextToken()
 	}
 
+	if p.curTokenIs(token.RBRACE) {
+		b.Rbrace = p.curToken
+	} else if p.curTokenIs(token.EOF) {
+		d := p.errorf(CodeUnclosedBlock, p.curToken.Pos, p.curToken.End, "expected next token to be }, got EOF instead")
+		d.Hints = append(d.Hints, fmt.Sprintf("the block was opened at %s", b.Token.Pos))
+	}
+
 	return b
 }
 
 func (p *Parser) parseGroupedExpression() ast.Expression {
+	lparen := p.curToken
 	p.nextToken()
 
 	exp := p.parseExpression(LOWEST)
 
 	if !p.expectPeek(token.RPAREN) {
-		return nil
+		return p.badExpression(lparen)
 	}
 
 	return exp
@@ -373,53 +684,131 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	lit := &ast.FunctionLiteral{Token: p.curToken}
 
 	if !p.expectPeek(token.LPAREN) {
-		return nil
+		return p.badExpression(lit.Token)
 	}
 
-	lit.Parameters = p.parseFunctionParameters()
+	if !p.parseFunctionParameters(lit) {
+		return p.badExpression(lit.Token)
+	}
 
 	if !p.expectPeek(token.LBRACE) {
-		return nil
+		return p.badExpression(lit.Token)
 	}
 
+	// A loop around the function literal does not extend into its body.
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
 	lit.Body = p.parseBlockStatement()
+	p.loopDepth = loopDepth
 
 	return lit
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	idents := []*ast.Identifier{}
+// parseFunctionParameters parses "(a, b = 10, ...rest)" into lit. A parameter
+// with a default may only be followed by other defaults or the rest parameter,
+// which must come last.
+func (p *Parser) parseFunctionParameters(lit *ast.FunctionLiteral) bool {
+	lit.Parameters = []*ast.Identifier{}
 
 	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
-		return idents
+		return true
 	}
 
-	p.nextToken()
+	defaults := []ast.Expression{}
+	hasDefaults := false
+
+	for {
+		if p.peekTokenIs(token.ELLIPSIS) {
+			p.nextToken()
+			if !p.expectPeek(token.IDENT) {
+				return false
+			}
+			lit.Rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			if p.peekTokenIs(token.COMMA) {
+				p.errorf(CodeInvalidParameter, lit.Rest.Pos(), lit.Rest.End(), "rest parameter %s must be last", lit.Rest.Value)
+				return false
+			}
+			break
+		}
 
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	idents = append(idents, ident)
+		if !p.expectPeek(token.IDENT) {
+			return false
+		}
+		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
-	for p.peekTokenIs(token.COMMA) {
-		p.nextToken()
+		var value ast.Expression
+		if p.peekTokenIs(token.ASSIGN) {
+			p.nextToken()
+			p.nextToken()
+			value = p.parseExpression(ASSIGN)
+			hasDefaults = true
+		} else if hasDefaults {
+			p.errorf(CodeInvalidParameter, ident.Pos(), ident.End(), "parameter %s without a default follows a parameter with a default", ident.Value)
+			return false
+		}
+
+		lit.Parameters = append(lit.Parameters, ident)
+		defaults = append(defaults, value)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
 		p.nextToken()
-		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-		idents = append(idents, ident)
 	}
 
-	if !p.expectPeek(token.RPAREN) {
-		return nil
+	if hasDefaults {
+		lit.Defaults = defaults
 	}
 
-	return idents
+	return p.expectPeek(token.RPAREN)
 }
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
-	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	if !p.parseCallArguments(exp) {
+		return p.badExpression(exp.Token)
+	}
+	exp.Rparen = p.curToken
 	return exp
 }
 
+// parseCallArguments parses positional arguments followed by "name: value" arguments.
+func (p *Parser) parseCallArguments(call *ast.CallExpression) bool {
+	call.Arguments = []ast.Expression{}
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return true
+	}
+
+	for {
+		p.nextToken()
+
+		if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.COLON) {
+			name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			p.nextToken()
+			p.nextToken()
+			arg := &ast.NamedArgument{Name: name, Value: p.parseExpression(LOWEST)}
+			call.NamedArguments = append(call.NamedArguments, arg)
+		} else {
+			arg := p.parseExpression(LOWEST)
+			if len(call.NamedArguments) > 0 {
+				p.errorf(CodeInvalidArgument, arg.Pos(), arg.End(), "positional argument follows named arguments")
+				return false
+			}
+			call.Arguments = append(call.Arguments, arg)
+		}
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	return p.expectPeek(token.RPAREN)
+}
+
 func (p *Parser) curTokenIs(t token.TokenType) bool {
 	return p.curToken.Type == t
 }
@@ -437,13 +826,77 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 	return false
 }
 
-func (p *Parser) Errors() []string {
+func (p *Parser) Errors() []*Diagnostic {
 	return p.errors
 }
 
+// lexerErrorCodes maps the kinds of lexer errors to diagnostic codes.
+var lexerErrorCodes = map[lexer.ErrorKind]string{
+	lexer.IllegalCharacter:    CodeIllegalCharacter,
+	lexer.UnterminatedComment: CodeUnterminatedComment,
+	lexer.UnterminatedString:  CodeUnterminatedString,
+	lexer.InvalidEscape:       CodeInvalidEscape,
+	lexer.InvalidUTF8:         CodeInvalidUTF8,
+	lexer.InvalidNumber:       CodeInvalidNumber,
+}
+
+// reportLexerErrors adds the errors the lexer found since the last call. They
+// do not start error recovery, since the lexer resumes after malformed input
+// on its own.
+func (p *Parser) reportLexerErrors() {
+	errs := p.l.Errors()
+	for _, e := range errs[p.lexerErrors:] {
+		p.errors = append(p.errors, &Diagnostic{
+			Severity: Error,
+			Code:     lexerErrorCodes[e.Kind],
+			Message:  e.Msg,
+			Pos:      e.Pos,
+			End:      e.End,
+		})
+	}
+	p.lexerErrors = len(errs)
+}
+
+// errorf records an error diagnostic for the span [pos, end). Errors reported
+// while recovering from a previous one are dropped, but the diagnostic is
+// still returned so that callers can attach hints unconditionally.
+func (p *Parser) errorf(code string, pos token.Position, end token.Position, format string, a ...interface{}) *Diagnostic {
+	d := &Diagnostic{
+		Severity: Error,
+		Code:     code,
+		Message:  fmt.Sprintf(format, a...),
+		Pos:      pos,
+		End:      end,
+	}
+
+	if !p.panicking {
+		p.panicking = true
+		p.errors = append(p.errors, d)
+	}
+
+	return d
+}
+
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	if p.peekTokenIs(token.ILLEGAL) {
+		// reported by the lexer
+		p.panicking = true
+		return
+	}
+	d := p.errorf(CodeUnexpectedToken, p.peekToken.Pos, p.peekToken.End, "expected next token to be %s, got %s instead", t, p.peekToken.Type)
+	if t == token.ASSIGN && p.peekTokenIs(token.EQ) {
+		d.Hints = append(d.Hints, "did you mean `=`?")
+	}
+}
+
+// badExpression returns a placeholder for a malformed expression starting at from.
+func (p *Parser) badExpression(from token.Token) ast.Expression {
+	return &ast.BadExpression{Token: from, To: p.curToken.End}
+}
+
+// badStatement returns a placeholder for a malformed statement starting at from.
+func (p *Parser) badStatement(from token.Token) ast.Statement {
+	return &ast.BadStatement{Token: from, To: p.curToken.End}
 }
 
 func (p *Parser) registerPrefix(tokenType token.TokenType, fn prefixParseFn) {
@@ -472,6 +925,10 @@ func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.curToken}
 
 	array.Elements = p.parseExpressionList(token.RBRACKET)
+	if array.Elements == nil {
+		return p.badExpression(array.Token)
+	}
+	array.Rbracket = p.curToken
 
 	return array
 }
@@ -479,15 +936,56 @@ func (p *Parser) parseArrayLiteral() ast.Expression {
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
 
+	if p.peekTokenIs(token.COLON) {
+		return p.parseSliceExpression(exp.Token, left, nil)
+	}
+
 	p.nextToken()
 	exp.Index = p.parseExpression(LOWEST)
 
+	if p.peekTokenIs(token.COLON) {
+		return p.parseSliceExpression(exp.Token, left, exp.Index)
+	}
+
 	if !p.expectPeek(token.RBRACKET) {
-		return nil
+		return p.badExpression(exp.Token)
+	}
+	exp.Rbracket = p.curToken
+
+	return exp
+}
+
+// parseSliceExpression parses the rest of left[low:high:step] from the first
+// colon on. Each of low, high and step may be omitted.
+func (p *Parser) parseSliceExpression(lbracket token.Token, left ast.Expression, low ast.Expression) ast.Expression {
+	exp := &ast.SliceExpression{Token: lbracket, Left: left, Low: low}
+
+	p.nextToken()
+	exp.High = p.parseSliceBound()
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		exp.Step = p.parseSliceBound()
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return p.badExpression(exp.Token)
 	}
+	exp.Rbracket = p.curToken
 
 	return exp
 }
+
+// parseSliceBound parses the expression after a colon in a slice, or returns
+// nil when it is omitted.
+func (p *Parser) parseSliceBound() ast.Expression {
+	if p.peekTokenIs(token.COLON) || p.peekTokenIs(token.RBRACKET) {
+		return nil
+	}
+
+	p.nextToken()
+	return p.parseExpression(LOWEST)
+}
 func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	list := []ast.Expression{}
 
@@ -521,7 +1019,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		key := p.parseExpression(LOWEST)
 
 		if !p.expectPeek(token.COLON) {
-			return nil
+			return p.badExpression(hash.Token)
 		}
 
 		p.nextToken()
@@ -530,24 +1028,41 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		hash.Pairs[key] = value
 
 		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
-			return nil
+			return p.badExpression(hash.Token)
 		}
 	}
 
 	if !p.expectPeek(token.RBRACE) {
-		return nil
+		return p.badExpression(hash.Token)
 	}
+	hash.Rbrace = p.curToken
 
 	return hash
 }
 
+// parseAssignExpression parses an assignment to a variable or to an element
+// of a variable, such as x = 1, a[0] += 1 or h["k"][1] = 2.
 func (p *Parser) parseAssignExpression(left ast.Expression) ast.Expression {
-	ident, ok := left.(*ast.Identifier)
-	if !ok {
-		return nil
+	assign := &ast.AssignExpression{Token: p.curToken, Operator: p.curToken.Literal}
+
+	target := left
+	for {
+		index, ok := target.(*ast.IndexExpression)
+		if !ok {
+			break
+		}
+		if assign.Index == nil {
+			assign.Index = index
+		}
+		target = index.Left
 	}
 
-	assign := &ast.AssignExpression{Token: p.curToken, Name: ident}
+	ident, ok := target.(*ast.Identifier)
+	if !ok {
+		p.errorf(CodeInvalidAssignTarget, left.Pos(), left.End(), "cannot assign to %s", left.String())
+		return p.badExpression(p.curToken)
+	}
+	assign.Name = ident
 
 	p.nextToken()
 	assign.Value = p.parseExpression(LOWEST)
@@ -231,6 +231,38 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14;", 3.14},
+		{".5;", 0.5},
+		{"1e-9;", 1e-9},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] isn't *ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+
+		float, ok := stmt.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("exp isn't *ast.FloatLiteral. got=%T", stmt.Expression)
+		}
+
+		if float.Value != tt.expected {
+			t.Errorf("float.Value is %v, want %v", float.Value, tt.expected)
+		}
+	}
+}
+
 func TestBooleanExpression(t *testing.T) {
 	input := "true;"
 
@@ -399,6 +431,7 @@ func TestParsingPrefixExpressions(t *testing.T) {
 		{"-alice", "-", "alice"},
 		{"!true", "!", true},
 		{"!false", "!", false},
+		{"~15", "~", 15},
 	}
 
 	for _, tt := range prefixTests {
@@ -448,6 +481,17 @@ func TestParsingInfixExpressions(t *testing.T) {
 		{"true == true", true, "==", true},
 		{"false == false", false, "==", false},
 		{"true != false", true, "!=", false},
+		{"5 <= 5", 5, "<=", 5},
+		{"5 >= 5", 5, ">=", 5},
+		{"true && false", true, "&&", false},
+		{"alice || bob", "alice", "||", "bob"},
+		{"5 % 5", 5, "%", 5},
+		{"5 ** 5", 5, "**", 5},
+		{"5 & 5", 5, "&", 5},
+		{"5 | 5", 5, "|", 5},
+		{"5 ^ 5", 5, "^", 5},
+		{"5 << 5", 5, "<<", 5},
+		{"5 >> 5", 5, ">>", 5},
 	}
 
 	for _, tt := range infixTests {
@@ -523,6 +567,74 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"3 + 4 * 5 == 3 * 1 + 4 * 5",
 			"((3 + (4 * 5)) == ((3 * 1) + (4 * 5)))",
 		},
+		{
+			"a <= b == c >= d",
+			"((a <= b) == (c >= d))",
+		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a && b || c && d",
+			"((a && b) || (c && d))",
+		},
+		{
+			"a < b && c == d || !e",
+			"(((a < b) && (c == d)) || (!e))",
+		},
+		{
+			"x = a || b",
+			"(x = (a || b))",
+		},
+		{
+			"0..n - 1",
+			"(0..(n - 1))",
+		},
+		{
+			"r = a..b || c",
+			"(r = (a..(b || c)))",
+		},
+		{
+			"a * b % c",
+			"((a * b) % c)",
+		},
+		{
+			"a + b % c",
+			"(a + (b % c))",
+		},
+		{
+			"2 ** 3 ** 2",
+			"(2 ** (3 ** 2))",
+		},
+		{
+			"-2 ** 2",
+			"(-(2 ** 2))",
+		},
+		{
+			"a * b ** c",
+			"(a * (b ** c))",
+		},
+		{
+			"a | b ^ c & d",
+			"(a | (b ^ (c & d)))",
+		},
+		{
+			"a & b == c",
+			"((a & b) == c)",
+		},
+		{
+			"1 << a + b",
+			"(1 << (a + b))",
+		},
+		{
+			"a & b << c",
+			"(a & (b << c))",
+		},
+		{
+			"~a & b",
+			"((~a) & b)",
+		},
 		{
 			"true",
 			"true",
@@ -729,6 +841,81 @@ func TestFunctionalParameterParsing(t *testing.T) {
 	}
 }
 
+func TestFunctionDefaultAndRestParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		params   []string
+		defaults []string
+		rest     string
+	}{
+		{"fn(a, b = 10) {}", []string{"a", "b"}, []string{"", "10"}, ""},
+		{"fn(a = x + 1, b = [1, 2]) {}", []string{"a", "b"}, []string{"(x + 1)", "[1, 2]"}, ""},
+		{"fn(...args) {}", []string{}, nil, "args"},
+		{"fn(a, b = 1, ...rest) {}", []string{"a", "b"}, []string{"", "1"}, "rest"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		function := stmt.Expression.(*ast.FunctionLiteral)
+		if len(function.Parameters) != len(tt.params) {
+			t.Fatalf("%s: len(Parameters) = %v, want %v", tt.input, len(function.Parameters), len(tt.params))
+		}
+		for i, ident := range tt.params {
+			testLiteralExpression(t, function.Parameters[i], ident)
+		}
+
+		if tt.defaults == nil && function.Defaults != nil {
+			t.Errorf("%s: Defaults = %v, want nil", tt.input, function.Defaults)
+		}
+		for i, def := range tt.defaults {
+			got := ""
+			if function.Defaults[i] != nil {
+				got = function.Defaults[i].String()
+			}
+			if got != def {
+				t.Errorf("%s: Defaults[%d] = %q, want %q", tt.input, i, got, def)
+			}
+		}
+
+		rest := ""
+		if function.Rest != nil {
+			rest = function.Rest.Value
+		}
+		if rest != tt.rest {
+			t.Errorf("%s: Rest = %q, want %q", tt.input, rest, tt.rest)
+		}
+	}
+}
+
+func TestCallNamedArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"f(b: 2, a: 1)", "f(b: 2, a: 1)"},
+		{"f(1, c: x + 1)", "f(1, c: (x + 1))"},
+		{"f(a, b)", "f(a, b)"},
+		{"f(g(x: 1))", "f(g(x: 1))"},
+		{"f({a: 1})", "f({a:1})"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("Program.String() returns %q, want %q", program.String(), tt.expected)
+		}
+	}
+}
+
 func TestCallExpressionParsing(t *testing.T) {
 	input := `add(1, 2 * 3, 4 + 5);`
 
@@ -811,6 +998,97 @@ func TestParsingIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestInterpolatedString(t *testing.T) {
+	input := `"hello ${name}, you have ${len(items) + 1} items"`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	str, ok := stmt.Expression.(*ast.InterpolatedString)
+	if !ok {
+		t.Fatalf("exp not *ast.InterpolatedString. got=%T", stmt.Expression)
+	}
+
+	expected := []string{"hello ", "name", ", you have ", "(len(items) + 1)", " items"}
+	if len(str.Parts) != len(expected) {
+		t.Fatalf("len(str.Parts) = %d, want %d", len(str.Parts), len(expected))
+	}
+	for i, part := range str.Parts {
+		if _, ok := part.(*ast.StringLiteral); ok != (i%2 == 0) {
+			t.Errorf("parts[%d] is %T", i, part)
+		}
+		if part.String() != expected[i] {
+			t.Errorf("parts[%d] is %q, want %q", i, part.String(), expected[i])
+		}
+	}
+
+	if str.String() != `"hello ${name}, you have ${(len(items) + 1)} items"` {
+		t.Errorf("str.String() wrong. got=%q", str.String())
+	}
+	if str.Pos().String() != "1:1" || str.End().String() != "1:50" {
+		t.Errorf("span is %s-%s, want 1:1-1:50", str.Pos(), str.End())
+	}
+}
+
+func TestParsingSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedLow  string
+		expectedHigh string
+		expectedStep string
+		expected     string
+	}{
+		{"a[1:3]", "1", "3", "", "(a[1:3])"},
+		{"a[:2]", "", "2", "", "(a[:2])"},
+		{"a[1:]", "1", "", "", "(a[1:])"},
+		{"a[:]", "", "", "", "(a[:])"},
+		{"a[::2]", "", "", "2", "(a[::2])"},
+		{"a[i + 1:-1:-1]", "(i + 1)", "(-1)", "(-1)", "(a[(i + 1):(-1):(-1)])"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		sliceExp, ok := stmt.Expression.(*ast.SliceExpression)
+		if !ok {
+			t.Fatalf("exp not *ast.SliceExpression. got=%T", stmt.Expression)
+		}
+
+		if !testIdentifier(t, sliceExp.Left, "a") {
+			return
+		}
+
+		for _, bound := range []struct {
+			name     string
+			exp      ast.Expression
+			expected string
+		}{
+			{"Low", sliceExp.Low, tt.expectedLow},
+			{"High", sliceExp.High, tt.expectedHigh},
+			{"Step", sliceExp.Step, tt.expectedStep},
+		} {
+			if bound.exp == nil {
+				if bound.expected != "" {
+					t.Errorf("%s: %s is nil, want %q", tt.input, bound.name, bound.expected)
+				}
+			} else if bound.exp.String() != bound.expected {
+				t.Errorf("%s: %s is %q, want %q", tt.input, bound.name, bound.exp.String(), bound.expected)
+			}
+		}
+
+		if sliceExp.String() != tt.expected {
+			t.Errorf("sliceExp.String() is %q, want %q", sliceExp.String(), tt.expected)
+		}
+	}
+}
+
 func TestParsingArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
@@ -979,6 +1257,55 @@ func TestAssignExpression(t *testing.T) {
 	}
 }
 
+func TestIndexAndCompoundAssignExpression(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedName     string
+		expectedOperator string
+		expected         string
+	}{
+		{"x += 1", "x", "+=", "(x += 1)"},
+		{"x -= y * 2", "x", "-=", "(x -= (y * 2))"},
+		{"x *= 2", "x", "*=", "(x *= 2)"},
+		{"x /= 2", "x", "/=", "(x /= 2)"},
+		{"x %= 2", "x", "%=", "(x %= 2)"},
+		{"a[0] = 1", "a", "=", "((a[0]) = 1)"},
+		{`h["k"][i + 1] += 2`, "h", "+=", "(((h[k])[(i + 1)]) += 2)"},
+		{"a[0] = b[1] = 2", "a", "=", "((a[0]) = ((b[1]) = 2))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("len(program.Statements) = %v, want %v", len(program.Statements), 1)
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] isn't *ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+
+		assign, ok := stmt.Expression.(*ast.AssignExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression isn't *ast.AssignExpression. got=%T", stmt.Expression)
+		}
+
+		if !testIdentifier(t, assign.Name, tt.expectedName) {
+			return
+		}
+		if assign.Operator != tt.expectedOperator {
+			t.Errorf("assign.Operator is %q, want %q", assign.Operator, tt.expectedOperator)
+		}
+		if assign.String() != tt.expected {
+			t.Errorf("assign.String() is %q, want %q", assign.String(), tt.expected)
+		}
+	}
+}
+
 func TestForStatement(t *testing.T) {
 	input := `for (var i = 0; i < 10; i = i + 1) { puts(i); }`
 
@@ -1027,3 +1354,262 @@ func TestForStatement(t *testing.T) {
 		t.Fatalf("len(stmt.Block.Statements) = %v, want %v", len(stmt.Block.Statements), 1)
 	}
 }
+
+func TestForInStatement(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedKey      string
+		expectedValue    string
+		expectedIterable string
+	}{
+		{"for (x in xs) { puts(x) }", "", "x", "xs"},
+		{"for (k, v in {1: 2}) { puts(k, v) }", "k", "v", "{1:2}"},
+		{"for (i in 0..len(xs)) { puts(i) }", "", "i", "(0..len(xs))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("len(program.Statements) = %v, want %v", len(program.Statements), 1)
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ForInStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] isn't *ast.ForInStatement. got=%T", program.Statements[0])
+		}
+
+		if tt.expectedKey == "" {
+			if stmt.Key != nil {
+				t.Errorf("stmt.Key is %q, want nil", stmt.Key.Value)
+			}
+		} else if !testIdentifier(t, stmt.Key, tt.expectedKey) {
+			return
+		}
+
+		if !testIdentifier(t, stmt.Value, tt.expectedValue) {
+			return
+		}
+
+		if stmt.Iterable.String() != tt.expectedIterable {
+			t.Errorf("stmt.Iterable is %q, want %q", stmt.Iterable.String(), tt.expectedIterable)
+		}
+
+		if len(stmt.Block.Statements) != 1 {
+			t.Errorf("len(stmt.Block.Statements) = %v, want %v", len(stmt.Block.Statements), 1)
+		}
+	}
+}
+
+func TestWhileStatement(t *testing.T) {
+	input := `while (x < 10) { if (x == 5) { break; } continue; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("len(program.Statements) = %v, want %v", len(program.Statements), 1)
+	}
+
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] isn't *ast.WhileStatement. got=%T", program.Statements[0])
+	}
+
+	if !testInfixExpression(t, stmt.Condition, "x", "<", 10) {
+		return
+	}
+
+	if len(stmt.Block.Statements) != 2 {
+		t.Fatalf("len(stmt.Block.Statements) = %v, want %v", len(stmt.Block.Statements), 2)
+	}
+
+	ifExp, ok := stmt.Block.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("stmt.Block.Statements[0] isn't an if expression. got=%T", stmt.Block.Statements[0])
+	}
+	if _, ok := ifExp.Consequence.Statements[0].(*ast.BreakStatement); !ok {
+		t.Errorf("consequence isn't *ast.BreakStatement. got=%T", ifExp.Consequence.Statements[0])
+	}
+
+	if _, ok := stmt.Block.Statements[1].(*ast.ContinueStatement); !ok {
+		t.Errorf("stmt.Block.Statements[1] isn't *ast.ContinueStatement. got=%T", stmt.Block.Statements[1])
+	}
+
+	if program.String() != "while ((x < 10)) { if(x == 5) break;continue; }" {
+		t.Errorf("program.String() wrong. got=%q", program.String())
+	}
+}
+
+func TestLoopStatementsWithSemicolon(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"while (x) { x = false; }; 1", "while (x) { (x = false) }1"},
+		{"for (x in xs) { puts(x) }; 1", "for (x in xs) { puts(x) }1"},
+		{"for (;;) { break; }; 1", "for (; ; ) { break; }1"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 2 {
+			t.Fatalf("len(program.Statements) = %v, want %v", len(program.Statements), 2)
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("program.String() is %q, want %q", program.String(), tt.expected)
+		}
+	}
+}
+
+func TestNodePositions(t *testing.T) {
+	input := `let add = fn(x, y) {
+	x + y;
+};
+add(1, [2, 3][0]);`
+
+	tests := []struct {
+		node        func(program *ast.Program) ast.Node
+		expectedPos string
+		expectedEnd string
+	}{
+		{func(p *ast.Program) ast.Node { return p }, "1:1", "4:18"},
+		{func(p *ast.Program) ast.Node { return p.Statements[0] }, "1:1", "3:2"},
+		{func(p *ast.Program) ast.Node {
+			return p.Statements[0].(*ast.LetStatement).Value.(*ast.FunctionLiteral).Body.Statements[0]
+		}, "2:2", "2:7"},
+		{func(p *ast.Program) ast.Node { return p.Statements[1] }, "4:1", "4:18"},
+		{func(p *ast.Program) ast.Node {
+			return p.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression).Arguments[1]
+		}, "4:8", "4:17"},
+	}
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	for i, tt := range tests {
+		node := tt.node(program)
+		if node.Pos().String() != tt.expectedPos {
+			t.Errorf("tests[%d] - %q Pos() is %s, want %s", i, node.String(), node.Pos(), tt.expectedPos)
+		}
+		if node.End().String() != tt.expectedEnd {
+			t.Errorf("tests[%d] - %q End() is %s, want %s", i, node.String(), node.End(), tt.expectedEnd)
+		}
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedErrors     int
+		expectedStatements []string
+	}{
+		{
+			"let = 5; let y = 10;",
+			1,
+			[]string{"<bad statement>", "let y = 10;"},
+		},
+		{
+			"let x = (1 + ; let y = 2;",
+			1,
+			[]string{"let x = <bad expression>;", "let y = 2;"},
+		},
+		{
+			"let x 5; x + ; let y = )",
+			3,
+			[]string{"<bad statement>", "(x + <bad expression>)", "let y = <bad expression>;"},
+		},
+		{
+			"let f = fn(x) { x + }; f(1);",
+			1,
+			[]string{"let f = fn(x)(x + <bad expression>);", "f(1)"},
+		},
+		{
+			`let h = {"a" 1}; return h;`,
+			1,
+			[]string{"let h = <bad expression>;", "return h;"},
+		},
+		{
+			"for (var i = 0 i < 10) { puts(i) }; var x = 1;",
+			1,
+			[]string{"<bad statement>", "var x = 1;"},
+		},
+		{
+			"1 = 2; [1, 2][0] = 3;",
+			2,
+			[]string{"<bad expression>", "<bad expression>"},
+		},
+		{
+			"break; while (true) { let f = fn() { continue; }; break; }",
+			2,
+			[]string{"<bad statement>", "while (true) { let f = fn()<bad statement>;break; }"},
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		if len(p.Errors()) != tt.expectedErrors {
+			t.Errorf("%q: wrong number of errors. expected=%d, got=%d (%q)", tt.input, tt.expectedErrors, len(p.Errors()), p.Errors())
+		}
+
+		if len(program.Statements) != len(tt.expectedStatements) {
+			t.Errorf("%q: wrong number of statements. expected=%d, got=%d (%q)", tt.input, len(tt.expectedStatements), len(program.Statements), program.String())
+			continue
+		}
+
+		for i, expected := range tt.expectedStatements {
+			if program.Statements[i].String() != expected {
+				t.Errorf("%q: statements[%d] is %q, want %q", tt.input, i, program.Statements[i].String(), expected)
+			}
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// add returns the sum of its arguments.
+let add = fn(x, y) {
+	x + y; /* the last expression
+	is /* nested */ returned */
+};
+add(1, /* two */ 2); // 3`
+
+	for _, mode := range []lexer.Mode{0, lexer.ScanComments} {
+		p := New(lexer.NewWithMode("", input, mode))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		expected := "let add = fn(x,y)(x + y);add(1, 2)"
+		if program.String() != expected {
+			t.Errorf("program.String() is %q, want %q", program.String(), expected)
+		}
+	}
+}
+
+func TestParsingTruncatedInputDoesNotPanic(t *testing.T) {
+	input := `let add = fn(x, y) { return x + y; };
+var h = {"one": [1, 2 * 3], "two": if (a < b) { a } else { b }};
+for (var i = 0; i < 10; i = i + 1) { h = add(h["one"][0], i); }
+`
+
+	for i := 0; i <= len(input); i++ {
+		l := lexer.New(input[:i])
+		p := New(l)
+		program := p.ParseProgram()
+		_ = program.String()
+	}
+}
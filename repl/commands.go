@@ -0,0 +1,164 @@
+package repl
+
+import (
+	"fmt"
+	"monkey/evaluator"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"monkey/token"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// command is a REPL meta-command, entered as ":name arg".
+type command struct {
+	name string
+	arg  string
+	help string
+	run  func(s *session, arg string)
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"env", "", "list the bindings of the session", cmdEnv},
+		{"ast", "<expr>", "print the syntax tree of <expr>", cmdAST},
+		{"tokens", "<expr>", "print the tokens of <expr>", cmdTokens},
+		{"type", "<expr>", "evaluate <expr> and print the type of its value", cmdType},
+		{"load", "<file>", "run <file> in the session", cmdLoad},
+		{"reset", "", "discard all bindings", cmdReset},
+		{"help", "", "show this help", cmdHelp},
+	}
+}
+
+func (s *session) runCommand(line string) {
+	name, arg := line[1:], ""
+	if i := strings.IndexAny(name, " \t"); i >= 0 {
+		name, arg = name[:i], strings.TrimSpace(name[i:])
+	}
+
+	for _, c := range commands {
+		if c.name == name {
+			c.run(s, arg)
+			return
+		}
+	}
+
+	fmt.Fprintf(s.out, "unknown command :%s, see :help\n", name)
+}
+
+func cmdEnv(s *session, arg string) {
+	for _, name := range s.env.Names() {
+		val, _ := s.env.Get(name)
+
+		kind := "let"
+		if val.IsMutable {
+			kind = "var"
+		}
+		fmt.Fprintf(s.out, "%s %s = %s\n", kind, name, inspectLine(val.Obj))
+	}
+}
+
+func cmdAST(s *session, arg string) {
+	p := parser.New(lexer.New(arg))
+
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		printParserErrors(s.out, arg, p.Errors())
+		return
+	}
+
+	dumpNode(s.out, program)
+}
+
+func cmdTokens(s *session, arg string) {
+	l := lexer.NewWithMode("", arg, lexer.ScanComments)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		fmt.Fprintf(s.out, "%-8s %-10s %q\n", tok.Pos, tok.Type, tok.Literal)
+	}
+}
+
+func cmdType(s *session, arg string) {
+	evaluated := s.evaluate("", arg, 0)
+	if evaluated != nil {
+		fmt.Fprintln(s.out, evaluated.Type())
+	}
+}
+
+func cmdLoad(s *session, arg string) {
+	if arg == "" {
+		fmt.Fprintln(s.out, "usage: :load <file>")
+		return
+	}
+
+	src, err := os.ReadFile(arg)
+	if err != nil {
+		fmt.Fprintln(s.out, err)
+		return
+	}
+
+	s.evaluate(arg, string(src), lexer.SkipShebang)
+}
+
+func cmdReset(s *session, arg string) {
+	s.env = object.NewEnvironment()
+}
+
+func cmdHelp(s *session, arg string) {
+	for _, c := range commands {
+		fmt.Fprintf(s.out, "  %-16s %s\n", strings.TrimSpace(":"+c.name+" "+c.arg), c.help)
+	}
+	fmt.Fprintf(s.out, "  %-16s %s\n", "quit", "leave the REPL")
+}
+
+// inspectLine returns obj.Inspect() shortened to a single line of at most 60
+// characters.
+func inspectLine(obj object.Object) string {
+	s := strings.Replace(obj.Inspect(), "\n", " ", -1)
+	if utf8.RuneCountInString(s) > 60 {
+		s = string([]rune(s)[:57]) + "..."
+	}
+	return s
+}
+
+// complete offers the commands when completing the first word of a ":" line,
+// and otherwise the keywords, builtins and names bound in the session.
+func (s *session) complete(line []rune, pos int) (int, []string) {
+	start := pos
+	for start > 0 && isIdentRune(line[start-1]) {
+		start--
+	}
+	prefix := string(line[start:pos])
+
+	var names []string
+	if start == 1 && line[0] == ':' {
+		for _, c := range commands {
+			names = append(names, c.name)
+		}
+	} else if prefix != "" {
+		names = append(names, token.Keywords()...)
+		names = append(names, evaluator.BuiltinNames()...)
+		names = append(names, s.env.Names()...)
+	}
+
+	seen := map[string]bool{}
+	var candidates []string
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) && !seen[name] {
+			seen[name] = true
+			candidates = append(candidates, name)
+		}
+	}
+	sort.Strings(candidates)
+
+	return start, candidates
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
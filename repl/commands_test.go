@@ -0,0 +1,123 @@
+package repl
+
+import (
+	"bytes"
+	"monkey/object"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		input    []string
+		expected string
+	}{
+		{
+			[]string{"let a = 1; var b = [1, 2];", ":env"},
+			"let a = 1\nvar b = [1, 2]\n",
+		},
+		{
+			[]string{`let s = "` + strings.Repeat("é", 70) + `";`, ":env"},
+			"let s = " + strings.Repeat("é", 57) + "...\n",
+		},
+		{
+			[]string{"let a = 1;", ":reset", ":env"},
+			"",
+		},
+		{
+			[]string{`var s = "x";`, ":type s", ":type len"},
+			"STRING\nBUILTIN\n",
+		},
+		{
+			[]string{":ast -a"},
+			"Program (1:1-1:3)\n" +
+				"  Statements: [1]\n" +
+				"    0: ExpressionStatement (1:1-1:3)\n" +
+				"      Expression: PrefixExpression (1:1-1:3)\n" +
+				"        Operator: \"-\"\n" +
+				"        Right: Identifier (1:2-1:3)\n" +
+				"          Value: \"a\"\n",
+		},
+		{
+			[]string{`:ast {"b": 1, "a": 2, "c": 3}`},
+			"Program (1:1-1:25)\n" +
+				"  Statements: [1]\n" +
+				"    0: ExpressionStatement (1:1-1:25)\n" +
+				"      Expression: HashLiteral (1:1-1:25)\n" +
+				"        Pairs: {3}\n" +
+				"          key: StringLiteral (1:2-1:5)\n" +
+				"            Value: \"b\"\n" +
+				"            value: IntegerLiteral (1:7-1:8)\n" +
+				"              Value: 1\n" +
+				"              Big: <nil>\n" +
+				"          key: StringLiteral (1:10-1:13)\n" +
+				"            Value: \"a\"\n" +
+				"            value: IntegerLiteral (1:15-1:16)\n" +
+				"              Value: 2\n" +
+				"              Big: <nil>\n" +
+				"          key: StringLiteral (1:18-1:21)\n" +
+				"            Value: \"c\"\n" +
+				"            value: IntegerLiteral (1:23-1:24)\n" +
+				"              Value: 3\n" +
+				"              Big: <nil>\n",
+		},
+		{
+			[]string{":tokens x+1"},
+			"1:1      IDENT      \"x\"\n" +
+				"1:2      +          \"+\"\n" +
+				"1:3      INT        \"1\"\n",
+		},
+		{
+			[]string{":tokens x // y"},
+			"1:1      IDENT      \"x\"\n" +
+				"1:3      COMMENT    \"// y\"\n",
+		},
+		{
+			[]string{":nope"},
+			"unknown command :nope, see :help\n",
+		},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		s := &session{out: &out, env: object.NewEnvironment()}
+
+		for _, line := range tt.input {
+			if line[0] == ':' {
+				s.runCommand(line)
+			} else {
+				s.evaluate("", line, 0)
+			}
+		}
+
+		if out.String() != tt.expected {
+			t.Errorf("%q: output is\n%s\nwant\n%s", tt.input, out.String(), tt.expected)
+		}
+	}
+}
+
+func TestLoadCommand(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "script.mk")
+	src := "#!/usr/bin/env monkey\nlet a = 1;\nvar b = a + 1;\n"
+	if err := os.WriteFile(script, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	s := &session{out: &out, env: object.NewEnvironment()}
+	s.runCommand(":load " + script)
+	s.runCommand(":env")
+
+	if expected := "let a = 1\nvar b = 2\n"; out.String() != expected {
+		t.Errorf("output is\n%s\nwant\n%s", out.String(), expected)
+	}
+
+	// interactive input keeps the line
+	out.Reset()
+	s.evaluate("", "#!x", 0)
+	if !bytes.Contains(out.Bytes(), []byte(`illegal character "#"`)) {
+		t.Errorf("output is %q, want an illegal character error", out.String())
+	}
+}
@@ -0,0 +1,85 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"monkey/ast"
+	"monkey/token"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	nodeType  = reflect.TypeOf((*ast.Node)(nil)).Elem()
+	tokenType = reflect.TypeOf(token.Token{})
+)
+
+// dumpNode writes the syntax tree rooted at node to out, one node per line.
+func dumpNode(out io.Writer, node ast.Node) {
+	dumpValue(out, "", reflect.ValueOf(node), 0)
+}
+
+func dumpValue(out io.Writer, label string, v reflect.Value, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			fmt.Fprintf(out, "%s%snil\n", indent, label)
+			return
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Ptr && v.Type().Implements(nodeType) {
+		if v.IsNil() {
+			fmt.Fprintf(out, "%s%snil\n", indent, label)
+			return
+		}
+		node := v.Interface().(ast.Node)
+		fmt.Fprintf(out, "%s%s%s (%s-%s)\n", indent, label, v.Type().Elem().Name(), node.Pos(), node.End())
+		dumpFields(out, v.Elem(), depth+1)
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		fmt.Fprintf(out, "%s%s[%d]\n", indent, label, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			dumpValue(out, fmt.Sprintf("%d: ", i), v.Index(i), depth+1)
+		}
+	case reflect.Map:
+		fmt.Fprintf(out, "%s%s{%d}\n", indent, label, v.Len())
+		for _, key := range sortedMapKeys(v) {
+			dumpValue(out, "key: ", key, depth+1)
+			dumpValue(out, "value: ", v.MapIndex(key), depth+2)
+		}
+	default:
+		fmt.Fprintf(out, "%s%s%#v\n", indent, label, v.Interface())
+	}
+}
+
+// sortedMapKeys returns the keys of the map v in a stable order: nodes in the
+// order they appear in the source, anything else by its printed value.
+func sortedMapKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		a, aok := keys[i].Interface().(ast.Node)
+		b, bok := keys[j].Interface().(ast.Node)
+		if aok && bok {
+			return a.Pos().Offset < b.Pos().Offset
+		}
+		return fmt.Sprintf("%#v", keys[i].Interface()) < fmt.Sprintf("%#v", keys[j].Interface())
+	})
+	return keys
+}
+
+func dumpFields(out io.Writer, v reflect.Value, depth int) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Type == tokenType {
+			continue
+		}
+		dumpValue(out, field.Name+": ", v.Field(i), depth)
+	}
+}
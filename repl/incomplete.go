@@ -0,0 +1,47 @@
+package repl
+
+import (
+	"monkey/lexer"
+	"monkey/token"
+)
+
+// isIncomplete reports whether src ends in the middle of a statement: inside
+// unbalanced brackets, a string or a block comment, or right after an
+// operator. The REPL keeps reading lines until the input is complete.
+func isIncomplete(src string) bool {
+	l := lexer.New(src)
+
+	depth := 0
+	var last token.Token
+
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LPAREN, token.LBRACE, token.LBRACKET:
+			depth++
+		case token.RPAREN, token.RBRACE, token.RBRACKET:
+			depth--
+		}
+		last = tok
+	}
+
+	if depth > 0 {
+		return true
+	}
+
+	for _, err := range l.Errors() {
+		if err.Kind == lexer.UnterminatedComment || err.Kind == lexer.UnterminatedString {
+			return true
+		}
+	}
+
+	switch last.Type {
+	case token.ASSIGN, token.PLUS, token.MINUS, token.BANG, token.ASTERISK, token.SLASH,
+		token.PERCENT, token.POW, token.BIT_AND, token.BIT_OR, token.BIT_XOR, token.BIT_NOT, token.SHL, token.SHR,
+		token.LT, token.GT, token.LT_EQ, token.GT_EQ, token.EQ, token.NOT_EQ, token.AND, token.OR,
+		token.PLUS_ASSIGN, token.MINUS_ASSIGN, token.ASTERISK_ASSIGN, token.SLASH_ASSIGN, token.PERCENT_ASSIGN,
+		token.COMMA, token.COLON, token.RANGE, token.IN:
+		return true
+	}
+
+	return false
+}
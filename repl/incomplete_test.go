@@ -0,0 +1,43 @@
+package repl
+
+import "testing"
+
+func TestIsIncomplete(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"let x = 5;", false},
+		{"let add = fn(x, y) {", true},
+		{"let add = fn(x, y) {\n\tx + y\n};", false},
+		{"add(1,", true},
+		{"add(1,\n2)", false},
+		{"[1, 2", true},
+		{"{\"a\": 1", true},
+		{"let x = 1 +", true},
+		{"let x =", true},
+		{"x == ", true},
+		{"for (i in 0..", true},
+		{"x +=", true},
+		{"/* note", true},
+		{"`raw", true},
+		{`"a ${`, true},
+		{`"a ${b} c`, true},
+		{`"a ${b} c"`, false},
+		{"`raw\nstring`", false},
+		{`"a\"`, true},
+		{"/* note */ x", false},
+		{"x + // more on the next line", true},
+		{"\"hello", true},
+		{"\"", true},
+		{"\"hello\"", false},
+		{"}", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if actual := isIncomplete(tt.input); actual != tt.expected {
+			t.Errorf("isIncomplete(%q) = %t, want %t", tt.input, actual, tt.expected)
+		}
+	}
+}
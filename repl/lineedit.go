@@ -0,0 +1,378 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// errInterrupted is returned by readLine when the line is abandoned with Ctrl-C.
+var errInterrupted = errors.New("interrupted")
+
+type lineReader interface {
+	readLine(prompt string) (string, error)
+}
+
+// newLineReader returns a line editor when in is a terminal, and a plain
+// line reader otherwise.
+func newLineReader(in io.Reader, out io.Writer, complete completer) lineReader {
+	if f, ok := in.(*os.File); ok && isTerminal(int(f.Fd())) {
+		return &lineEditor{
+			in:       bufio.NewReader(f),
+			out:      out,
+			history:  loadHistory(historyPath()),
+			complete: complete,
+			rawMode:  func() (func(), error) { return makeRaw(int(f.Fd())) },
+		}
+	}
+
+	return &plainReader{scanner: bufio.NewScanner(in), out: out}
+}
+
+type plainReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func (r *plainReader) readLine(prompt string) (string, error) {
+	io.WriteString(r.out, prompt)
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.scanner.Text(), nil
+}
+
+// completer returns the candidates for the word ending at pos in line,
+// along with the position the word starts at.
+type completer func(line []rune, pos int) (start int, candidates []string)
+
+// lineEditor reads lines from a terminal with emacs-style editing keys,
+// history navigation, reverse search (Ctrl-R) and tab completion.
+type lineEditor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	history  *history
+	complete completer
+	rawMode  func() (restore func(), err error)
+
+	prompt  string
+	buf     []rune
+	pos     int
+	histIdx int    // index of the history entry shown, len(entries) for the new line
+	pending []rune // the new line, kept while browsing the history
+}
+
+func ctrl(c rune) rune {
+	return c & 0x1f
+}
+
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	if e.rawMode != nil {
+		restore, err := e.rawMode()
+		if err != nil {
+			return e.readCooked(prompt)
+		}
+		defer restore()
+	}
+
+	e.prompt, e.buf, e.pos = prompt, nil, 0
+	e.histIdx, e.pending = len(e.history.entries), nil
+	e.refresh()
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			if err == io.EOF && len(e.buf) > 0 {
+				return e.accept(), nil
+			}
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			return e.accept(), nil
+		case ctrl('C'):
+			io.WriteString(e.out, "^C\r\n")
+			return "", errInterrupted
+		case ctrl('D'):
+			if len(e.buf) == 0 {
+				io.WriteString(e.out, "\r\n")
+				return "", io.EOF
+			}
+			e.deleteForward()
+		case ctrl('R'):
+			if e.reverseSearch() {
+				return e.accept(), nil
+			}
+		case '\t':
+			e.completeWord()
+		case 0x1b:
+			e.escapeSequence()
+		default:
+			e.editKey(r)
+		}
+
+		e.refresh()
+	}
+}
+
+// readCooked reads a line without editing, for terminals that cannot be put in raw mode.
+func (e *lineEditor) readCooked(prompt string) (string, error) {
+	io.WriteString(e.out, prompt)
+	line, err := e.in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	e.history.add(line)
+	return line, nil
+}
+
+// editKey handles the keys that edit the line or move the cursor.
+func (e *lineEditor) editKey(r rune) {
+	switch r {
+	case ctrl('A'):
+		e.pos = 0
+	case ctrl('E'):
+		e.pos = len(e.buf)
+	case ctrl('B'):
+		if e.pos > 0 {
+			e.pos--
+		}
+	case ctrl('F'):
+		if e.pos < len(e.buf) {
+			e.pos++
+		}
+	case ctrl('H'), 0x7f:
+		if e.pos > 0 {
+			e.buf = append(e.buf[:e.pos-1], e.buf[e.pos:]...)
+			e.pos--
+		}
+	case ctrl('K'):
+		e.buf = e.buf[:e.pos]
+	case ctrl('U'):
+		e.buf = append([]rune{}, e.buf[e.pos:]...)
+		e.pos = 0
+	case ctrl('W'):
+		start := e.pos
+		for start > 0 && unicode.IsSpace(e.buf[start-1]) {
+			start--
+		}
+		for start > 0 && !unicode.IsSpace(e.buf[start-1]) {
+			start--
+		}
+		e.buf = append(e.buf[:start], e.buf[e.pos:]...)
+		e.pos = start
+	case ctrl('L'):
+		io.WriteString(e.out, "\x1b[H\x1b[2J")
+	case ctrl('P'):
+		e.historyPrev()
+	case ctrl('N'):
+		e.historyNext()
+	default:
+		if unicode.IsPrint(r) {
+			e.insert([]rune{r})
+		}
+	}
+}
+
+// escapeSequence handles the ANSI sequences sent by the arrow, home, end and delete keys.
+func (e *lineEditor) escapeSequence() {
+	r, _, err := e.in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return
+	}
+
+	seq := ""
+	for {
+		r, _, err = e.in.ReadRune()
+		if err != nil {
+			return
+		}
+		seq += string(r)
+		if r < '0' || r > '9' {
+			break
+		}
+	}
+
+	switch seq {
+	case "A":
+		e.historyPrev()
+	case "B":
+		e.historyNext()
+	case "C":
+		e.editKey(ctrl('F'))
+	case "D":
+		e.editKey(ctrl('B'))
+	case "H", "1~", "7~":
+		e.pos = 0
+	case "F", "4~", "8~":
+		e.pos = len(e.buf)
+	case "3~":
+		e.deleteForward()
+	}
+}
+
+func (e *lineEditor) insert(rs []rune) {
+	buf := make([]rune, 0, len(e.buf)+len(rs))
+	buf = append(buf, e.buf[:e.pos]...)
+	buf = append(buf, rs...)
+	buf = append(buf, e.buf[e.pos:]...)
+	e.buf = buf
+	e.pos += len(rs)
+}
+
+func (e *lineEditor) deleteForward() {
+	if e.pos < len(e.buf) {
+		e.buf = append(e.buf[:e.pos], e.buf[e.pos+1:]...)
+	}
+}
+
+func (e *lineEditor) historyPrev() {
+	if e.histIdx == 0 {
+		return
+	}
+	if e.histIdx == len(e.history.entries) {
+		e.pending = e.buf
+	}
+	e.histIdx--
+	e.setLine([]rune(e.history.entries[e.histIdx]))
+}
+
+func (e *lineEditor) historyNext() {
+	if e.histIdx >= len(e.history.entries) {
+		return
+	}
+	e.histIdx++
+	if e.histIdx == len(e.history.entries) {
+		e.setLine(e.pending)
+	} else {
+		e.setLine([]rune(e.history.entries[e.histIdx]))
+	}
+}
+
+func (e *lineEditor) setLine(line []rune) {
+	e.buf = append([]rune{}, line...)
+	e.pos = len(e.buf)
+}
+
+// reverseSearch runs an incremental search backwards through the history.
+// The match is left in the buffer; it reports whether Enter was pressed to submit it.
+func (e *lineEditor) reverseSearch() bool {
+	origBuf, origPos := e.buf, e.pos
+	query := []rune{}
+	match := -1
+
+	for {
+		found := ""
+		if match >= 0 {
+			found = e.history.entries[match]
+		}
+		fmt.Fprintf(e.out, "\r(reverse-i-search)`%s': %s\x1b[K", string(query), found)
+
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return false
+		}
+
+		switch {
+		case r == ctrl('R'):
+			if match >= 0 {
+				if i := e.history.search(string(query), match); i >= 0 {
+					match = i
+				}
+			}
+		case r == ctrl('H') || r == 0x7f:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				match = e.history.search(string(query), len(e.history.entries))
+			}
+		case r == ctrl('G') || r == ctrl('C'):
+			e.buf, e.pos = origBuf, origPos
+			return false
+		case r == '\r' || r == '\n':
+			if match >= 0 {
+				e.setLine([]rune(found))
+			}
+			return true
+		case unicode.IsPrint(r):
+			query = append(query, r)
+			from := len(e.history.entries)
+			if match >= 0 {
+				from = match + 1
+			}
+			match = e.history.search(string(query), from)
+		default:
+			// any other key ends the search and keeps the match
+			if match >= 0 {
+				e.setLine([]rune(found))
+			}
+			if r == 0x1b {
+				e.escapeSequence()
+			} else if r != '\t' {
+				e.editKey(r)
+			}
+			return false
+		}
+	}
+}
+
+// completeWord completes the word before the cursor. When several candidates
+// share no longer prefix than what was typed, they are listed below the line.
+func (e *lineEditor) completeWord() {
+	if e.complete == nil {
+		return
+	}
+
+	start, candidates := e.complete(e.buf, e.pos)
+	if len(candidates) == 0 {
+		return
+	}
+
+	typed := e.pos - start
+	prefix := []rune(commonPrefix(candidates))
+	if len(prefix) > typed {
+		e.insert(prefix[typed:])
+		return
+	}
+
+	if len(candidates) > 1 {
+		io.WriteString(e.out, "\r\n"+strings.Join(candidates, "  ")+"\r\n")
+	}
+}
+
+func commonPrefix(words []string) string {
+	prefix := []rune(words[0])
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, string(prefix)) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return string(prefix)
+}
+
+// accept finishes the line being edited and records it in the history.
+func (e *lineEditor) accept() string {
+	e.pos = len(e.buf)
+	e.refresh()
+	io.WriteString(e.out, "\r\n")
+
+	line := string(e.buf)
+	e.history.add(line)
+	return line
+}
+
+// refresh redraws the prompt and the line, then puts the terminal cursor at pos.
+func (e *lineEditor) refresh() {
+	io.WriteString(e.out, "\r"+e.prompt+string(e.buf)+"\x1b[K")
+	if n := len(e.buf) - e.pos; n > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", n)
+	}
+}
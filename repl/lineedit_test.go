@@ -0,0 +1,91 @@
+package repl
+
+import (
+	"bufio"
+	"io"
+	"monkey/object"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLineEditor(t *testing.T) {
+	s := &session{out: io.Discard, env: object.NewEnvironment()}
+	s.env.Set("counter", &object.Integer{Value: 1}, true)
+
+	tests := []struct {
+		history  []string
+		keys     string
+		expected []string
+	}{
+		{nil, "abc\r", []string{"abc"}},
+		// cursor movement and editing
+		{nil, "ac\x1b[Db\r", []string{"abc"}},
+		{nil, "bc\x01a\x05d\r", []string{"abcd"}},
+		{nil, "abc\x7f\x7fx\r", []string{"ax"}},
+		{nil, "abcd\x02\x02\x0b\r", []string{"ab"}},
+		{nil, "abcd\x02\x02\x15\r", []string{"cd"}},
+		{nil, "let x = 1\x17\x17\r", []string{"let x "}},
+		{nil, "abc\x01\x1b[3~\r", []string{"bc"}},
+		// history
+		{[]string{"first", "second"}, "\x1b[A\r", []string{"second"}},
+		{[]string{"first", "second"}, "\x1b[A\x1b[A\x1b[A\r", []string{"first"}},
+		{[]string{"first", "second"}, "new\x1b[A\x1b[B\r", []string{"new"}},
+		{nil, "one\rtwo\r\x10\x10\r", []string{"one", "two", "one"}},
+		// reverse search
+		{[]string{"let a = 1", "puts(a)", "let b = 2"}, "\x12let\r", []string{"let b = 2"}},
+		{[]string{"let a = 1", "puts(a)", "let b = 2"}, "\x12let\x12\r", []string{"let a = 1"}},
+		{[]string{"let a = 1", "puts(a)"}, "\x12puts\x05;\r", []string{"puts(a);"}},
+		{[]string{"let a = 1"}, "x\x12let\x07\r", []string{"x"}},
+		// completion
+		{nil, "ret\t 1\r", []string{"return 1"}},
+		{nil, "cou\t\r", []string{"counter"}},
+		{nil, "put\t(1)\r", []string{"puts(1)"}},
+		{nil, "pu\t\r", []string{"pu"}},
+		{nil, "l\t\r", []string{"l"}},
+		{nil, ":he\t\r", []string{":help"}},
+		// interrupt and end of input
+		{nil, "abc\x03def\r", []string{"", "def"}},
+		{nil, "abc", []string{"abc"}},
+	}
+
+	for _, tt := range tests {
+		e := &lineEditor{
+			in:       bufio.NewReader(strings.NewReader(tt.keys)),
+			out:      io.Discard,
+			history:  &history{entries: tt.history},
+			complete: s.complete,
+		}
+
+		var lines []string
+		for {
+			line, err := e.readLine(PROMPT)
+			if err == io.EOF {
+				break
+			}
+			if err != nil && err != errInterrupted {
+				t.Fatalf("%q: unexpected error: %s", tt.keys, err)
+			}
+			lines = append(lines, line)
+		}
+
+		if !reflect.DeepEqual(lines, tt.expected) {
+			t.Errorf("%q: wrong lines. expected=%q, got=%q", tt.keys, tt.expected, lines)
+		}
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	h := loadHistory(path)
+	for _, line := range []string{"let a = 1", "", "a", "a", "puts(a)"} {
+		h.add(line)
+	}
+
+	expected := []string{"let a = 1", "a", "puts(a)"}
+	if got := loadHistory(path).entries; !reflect.DeepEqual(got, expected) {
+		t.Errorf("wrong history. expected=%q, got=%q", expected, got)
+	}
+}
@@ -1,44 +1,59 @@
 package repl
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"monkey/evaluator"
 	"monkey/lexer"
 	"monkey/object"
 	"monkey/parser"
+	"strings"
 )
 
 const PROMPT = ">> "
+const CONTINUATION_PROMPT = ".. "
+
+// session is the state shared by the statements and commands entered in one REPL run.
+type session struct {
+	out io.Writer
+	env *object.Environment
+}
 
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
-	env := object.NewEnvironment()
+	s := &session{out: out, env: object.NewEnvironment()}
+	lines := newLineReader(in, out, s.complete)
 
 	for {
-		fmt.Printf(PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
+		line, err := lines.readLine(PROMPT)
+		if err == errInterrupted {
+			continue
+		}
+		if err != nil {
 			return
 		}
 
-		line := scanner.Text()
 		if line == "q" || line == "quit" {
 			fmt.Printf("quit\n")
 			break
 		}
 
-		l := lexer.New(line)
-		p := parser.New(l)
-
-		program := p.ParseProgram()
-		if len(p.Errors()) > 0 {
-			printParserErrors(out, p.Errors())
+		if strings.HasPrefix(line, ":") {
+			s.runCommand(line)
 			continue
 		}
 
-		evaluated := evaluator.Eval(program, env)
+		for isIncomplete(line) {
+			more, err := lines.readLine(CONTINUATION_PROMPT)
+			if err == errInterrupted {
+				line = ""
+			}
+			if err != nil {
+				break
+			}
+			line += "\n" + more
+		}
+
+		evaluated := s.evaluate("", line, 0)
 		if evaluated != nil {
 			io.WriteString(out, evaluated.Inspect())
 			io.WriteString(out, "\n")
@@ -46,9 +61,30 @@ func Start(in io.Reader, out io.Writer) {
 	}
 }
 
-func printParserErrors(out io.Writer, errors []string) {
-	io.WriteString(out, "parse errors:\n")
-	for _, msg := range errors {
-		io.WriteString(out, fmt.Sprintf("\t%s\n", msg))
+// evaluate runs src, lexed with mode, in the session environment. Parse and
+// runtime errors are written to out, in which case nil is returned.
+func (s *session) evaluate(filename string, src string, mode lexer.Mode) object.Object {
+	l := lexer.NewWithMode(filename, src, mode)
+	p := parser.New(l)
+
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		printParserErrors(s.out, src, p.Errors())
+		return nil
+	}
+
+	evaluated := evaluator.Eval(program, s.env)
+	if err, ok := evaluated.(*object.Error); ok {
+		io.WriteString(s.out, err.Traceback())
+		io.WriteString(s.out, "\n")
+		return nil
+	}
+
+	return evaluated
+}
+
+func printParserErrors(out io.Writer, src string, errors []*parser.Diagnostic) {
+	for _, d := range errors {
+		io.WriteString(out, d.Render(src))
 	}
 }
@@ -0,0 +1,43 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package repl
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func isTerminal(fd int) bool {
+	var t syscall.Termios
+	return ioctlTermios(fd, ioctlReadTermios, &t) == nil
+}
+
+// makeRaw puts the terminal into raw mode and returns a function restoring
+// the previous mode. Output processing is left on, so "\n" still starts a new line.
+func makeRaw(fd int) (func(), error) {
+	var old syscall.Termios
+	if err := ioctlTermios(fd, ioctlReadTermios, &old); err != nil {
+		return nil, err
+	}
+
+	raw := old
+	raw.Iflag &^= syscall.BRKINT | syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.IEXTEN | syscall.ISIG
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+
+	if err := ioctlTermios(fd, ioctlWriteTermios, &raw); err != nil {
+		return nil, err
+	}
+
+	return func() { ioctlTermios(fd, ioctlWriteTermios, &old) }, nil
+}
+
+func ioctlTermios(fd int, req uintptr, t *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), req, uintptr(unsafe.Pointer(t)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}